@@ -10,6 +10,7 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Span() token.Span // the source range covered by the node
 }
 
 // All statement nodes implement this
@@ -24,6 +25,13 @@ type Expression interface {
 	expressionNode()
 }
 
+// Patterns are what fr, stalk and parameters bind values to: a plain name,
+// or an [a, b] or {a, b} pattern that unpacks an array or a hash
+type Pattern interface {
+	Node
+	patternNode()
+}
+
 type Program struct {
 	Statements []Statement
 }
@@ -36,6 +44,17 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Span() token.Span {
+	if len(p.Statements) == 0 {
+		return token.Span{}
+	}
+
+	return token.Span{
+		Start: p.Statements[0].Span().Start,
+		End:   p.Statements[len(p.Statements)-1].Span().End,
+	}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 
@@ -48,13 +67,23 @@ func (p *Program) String() string {
 
 // Statements
 type LetStatement struct {
-	Token token.Token // the token.LET token
-	Name  *Identifier
-	Value Expression
+	Token    token.Token // the token.LET token, or token.CONST for deadass
+	Name     Pattern
+	Value    Expression
+	Constant bool // true for deadass x = ..., which can't be reassigned
 }
 
 func (ls *LetStatement) statementNode()       {}
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
+func (ls *LetStatement) Span() token.Span {
+	if ls.Value != nil {
+		return spanTo(ls.Token, ls.Value)
+	}
+	if ls.Name != nil {
+		return spanTo(ls.Token, ls.Name)
+	}
+	return ls.Token.Span()
+}
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
@@ -78,6 +107,7 @@ type ReturnStatement struct {
 
 func (rs *ReturnStatement) statementNode()       {}
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatement) Span() token.Span     { return spanTo(rs.Token, rs.ReturnValue) }
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 
@@ -93,17 +123,21 @@ func (rs *ReturnStatement) String() string {
 }
 
 type AssignmentStatement struct {
-	Token token.Token // the '=' token
-	Name  *Identifier
-	Value Expression
+	Token    token.Token // the '=' token, or '+=' and friends
+	Name     *Identifier
+	Operator string // the operator applied by a compound assignment like +=, empty for =
+	Value    Expression
 }
 
 func (as *AssignmentStatement) statementNode()       {}
 func (as *AssignmentStatement) TokenLiteral() string { return as.Token.Literal }
+func (as *AssignmentStatement) Span() token.Span {
+	return token.Span{Start: as.Name.Span().Start, End: endOf(as.Value, as.Token.End)}
+}
 func (as *AssignmentStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(as.Name.String())
-	out.WriteString(" = ")
+	out.WriteString(" " + as.Operator + "= ")
 	if as.Value != nil {
 		out.WriteString(as.Value.String())
 	}
@@ -112,17 +146,21 @@ func (as *AssignmentStatement) String() string {
 }
 
 type IndexExpressionAssignmentStatement struct {
-	Token token.Token
-	Left  *IndexExpression
-	Value Expression
+	Token    token.Token // the '=' token, or '+=' and friends
+	Left     *IndexExpression
+	Operator string // the operator applied by a compound assignment like +=, empty for =
+	Value    Expression
 }
 
 func (aes *IndexExpressionAssignmentStatement) statementNode()       {}
 func (aes *IndexExpressionAssignmentStatement) TokenLiteral() string { return aes.Token.Literal }
+func (aes *IndexExpressionAssignmentStatement) Span() token.Span {
+	return token.Span{Start: aes.Left.Span().Start, End: endOf(aes.Value, aes.Token.End)}
+}
 func (aes *IndexExpressionAssignmentStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(aes.Left.String())
-	out.WriteString(" = ")
+	out.WriteString(" " + aes.Operator + "= ")
 	if aes.Value != nil {
 		out.WriteString(aes.Value.String())
 	}
@@ -136,6 +174,7 @@ type ContinueStatement struct {
 
 func (cs *ContinueStatement) statementNode()       {}
 func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) Span() token.Span     { return cs.Token.Span() }
 func (cs *ContinueStatement) String() string {
 	return cs.TokenLiteral() + ";"
 }
@@ -146,6 +185,7 @@ type BreakStatement struct {
 
 func (bs *BreakStatement) statementNode()       {}
 func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) Span() token.Span     { return bs.Token.Span() }
 func (bs *BreakStatement) String() string {
 	return bs.TokenLiteral() + ";"
 }
@@ -157,6 +197,12 @@ type ExpressionStatement struct {
 
 func (es *ExpressionStatement) statementNode()       {}
 func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExpressionStatement) Span() token.Span {
+	if es.Expression != nil {
+		return es.Expression.Span()
+	}
+	return es.Token.Span()
+}
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -167,10 +213,17 @@ func (es *ExpressionStatement) String() string {
 type BlockStatement struct {
 	Token      token.Token // the { token
 	Statements []Statement
+	Rbrace     token.Token // the } token
 }
 
 func (bs *BlockStatement) statementNode()       {}
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Span() token.Span {
+	if bs.Rbrace.End.IsValid() {
+		return token.Span{Start: bs.Token.Start, End: bs.Rbrace.End}
+	}
+	return bs.Token.Span()
+}
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -184,17 +237,22 @@ func (bs *BlockStatement) String() string {
 type ForStatement struct {
 	Token token.Token
 	Items Expression
-	Key   *Identifier
+	Key   Pattern
+	Value Pattern // the second name in stalk (k, v in items), nil when there's only one
 	Body  *BlockStatement
 }
 
 func (fs *ForStatement) statementNode()       {}
 func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) Span() token.Span     { return spanToBlock(fs.Token, fs.Body) }
 func (fs *ForStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString("stalk(")
-	out.WriteString(fs.Key.Value)
+	out.WriteString(fs.Key.String())
+	if fs.Value != nil {
+		out.WriteString(", " + fs.Value.String())
+	}
 	out.WriteString(" in ")
 	out.WriteString(fs.Items.String() + ") ")
 	out.WriteString(fs.Body.String())
@@ -210,6 +268,7 @@ type WhileStatement struct {
 
 func (ws *WhileStatement) statementNode()       {}
 func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStatement) Span() token.Span     { return spanToBlock(ws.Token, ws.Body) }
 func (ws *WhileStatement) String() string {
 	var out bytes.Buffer
 
@@ -223,13 +282,15 @@ func (ws *WhileStatement) String() string {
 
 type FunctionStatement struct {
 	Token      token.Token
-	Parameters []*Identifier
+	Parameters []*Parameter
 	Body       *BlockStatement
 	Name       *Identifier
+	Constant   bool // true for deadass cook name() {...}
 }
 
 func (fs *FunctionStatement) statementNode()       {}
 func (fs *FunctionStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *FunctionStatement) Span() token.Span     { return spanToBlock(fs.Token, fs.Body) }
 func (fs *FunctionStatement) String() string {
 	var out bytes.Buffer
 
@@ -238,6 +299,9 @@ func (fs *FunctionStatement) String() string {
 		params = append(params, p.String())
 	}
 
+	if fs.Constant {
+		out.WriteString("deadass ")
+	}
 	out.WriteString(fs.TokenLiteral())
 	out.WriteString(" " + fs.Name.Value)
 
@@ -256,7 +320,9 @@ type Identifier struct {
 }
 
 func (i *Identifier) expressionNode()      {}
+func (i *Identifier) patternNode()         {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
+func (i *Identifier) Span() token.Span     { return i.Token.Span() }
 func (i *Identifier) String() string       { return i.Value }
 
 type Boolean struct {
@@ -266,6 +332,7 @@ type Boolean struct {
 
 func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
+func (b *Boolean) Span() token.Span     { return b.Token.Span() }
 func (b *Boolean) String() string       { return b.Token.Literal }
 
 type Null struct {
@@ -274,6 +341,7 @@ type Null struct {
 
 func (n *Null) expressionNode()      {}
 func (n *Null) TokenLiteral() string { return n.Token.Literal }
+func (n *Null) Span() token.Span     { return n.Token.Span() }
 func (n *Null) String() string       { return n.Token.Literal }
 
 type IntegerLiteral struct {
@@ -283,6 +351,7 @@ type IntegerLiteral struct {
 
 func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
+func (il *IntegerLiteral) Span() token.Span     { return il.Token.Span() }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
 type FloatLiteral struct {
@@ -292,6 +361,7 @@ type FloatLiteral struct {
 
 func (fl *FloatLiteral) expressionNode()      {}
 func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) Span() token.Span     { return fl.Token.Span() }
 func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
 
 type PrefixExpression struct {
@@ -302,6 +372,7 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Span() token.Span     { return spanTo(pe.Token, pe.Right) }
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -325,6 +396,9 @@ type InfixExpression struct {
 
 func (ie *InfixExpression) expressionNode()      {}
 func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *InfixExpression) Span() token.Span {
+	return token.Span{Start: startOf(ie.Left, ie.Token.Start), End: endOf(ie.Right, ie.Token.End)}
+}
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -337,6 +411,39 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
+// RangeExpression is start..end, optionally followed by `by step`
+type RangeExpression struct {
+	Token token.Token // The .. token
+	Start Expression
+	End   Expression
+	Step  Expression // nil when no step was given
+}
+
+func (re *RangeExpression) expressionNode()      {}
+func (re *RangeExpression) TokenLiteral() string { return re.Token.Literal }
+func (re *RangeExpression) Span() token.Span {
+	end := endOf(re.End, re.Token.End)
+	if re.Step != nil {
+		end = re.Step.Span().End
+	}
+	return token.Span{Start: startOf(re.Start, re.Token.Start), End: end}
+}
+func (re *RangeExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(re.Start.String())
+	out.WriteString("..")
+	out.WriteString(re.End.String())
+	if re.Step != nil {
+		out.WriteString(" by ")
+		out.WriteString(re.Step.String())
+	}
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type ElseIfExpression struct {
 	Token       token.Token
 	Condition   Expression
@@ -352,6 +459,16 @@ type IfExpression struct {
 
 func (ie *IfExpression) expressionNode()      {}
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) Span() token.Span {
+	switch {
+	case ie.Alternative != nil:
+		return spanToBlock(ie.Token, ie.Alternative)
+	case len(ie.ElseIfs) > 0:
+		return spanToBlock(ie.Token, ie.ElseIfs[len(ie.ElseIfs)-1].Consequence)
+	default:
+		return spanToBlock(ie.Token, ie.Consequence)
+	}
+}
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -379,12 +496,108 @@ func (ie *IfExpression) String() string {
 
 type FunctionLiteral struct {
 	Token      token.Token // The 'fn' token
-	Parameters []*Identifier
+	Parameters []*Parameter
 	Body       *BlockStatement
 }
 
+// Parameter is one parameter of a function: a plain name, a name with a
+// default like greeting = "yo", or a ...rest parameter that collects any
+// extra arguments into an array. The items of an [a, b] pattern are
+// Parameters too, since they can have defaults and a ...rest just the same.
+type Parameter struct {
+	Name    Pattern    // always an *Identifier for ...rest
+	Default Expression // nil when the parameter has to be given
+	Rest    bool
+}
+
+func (p *Parameter) String() string {
+	switch {
+	case p.Rest:
+		return "..." + p.Name.String()
+	case p.Default != nil:
+		return p.Name.String() + " = " + p.Default.String()
+	default:
+		return p.Name.String()
+	}
+}
+
+// ArrayPattern is [a, b = 2, ...rest], which unpacks an array, range or
+// string item by item
+type ArrayPattern struct {
+	Token    token.Token // the '[' token
+	Elements []*Parameter
+	Rbracket token.Token // the ']' token
+}
+
+func (ap *ArrayPattern) patternNode()         {}
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) Span() token.Span {
+	return token.Span{Start: ap.Token.Start, End: ap.Rbracket.End}
+}
+func (ap *ArrayPattern) String() string {
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashPattern is {name, age: years, ...rest}, which unpacks a hash by its
+// string keys
+type HashPattern struct {
+	Token  token.Token // the '{' token
+	Pairs  []*HashPatternPair
+	Rbrace token.Token // the '}' token
+}
+
+// HashPatternPair is one key of a HashPattern. In the short form {name}
+// Key and Value.Name are the same identifier, and for ...rest Key is nil.
+type HashPatternPair struct {
+	Key   *Identifier
+	Value *Parameter
+}
+
+func (hp *HashPattern) patternNode()         {}
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashPattern) Span() token.Span {
+	return token.Span{Start: hp.Token.Start, End: hp.Rbrace.End}
+}
+func (hp *HashPattern) String() string {
+	pairs := []string{}
+	for _, pair := range hp.Pairs {
+		if pair.Key == nil || Pattern(pair.Key) == pair.Value.Name {
+			pairs = append(pairs, pair.Value.String())
+			continue
+		}
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// PatternNames returns every name a pattern binds, in order
+func PatternNames(pattern Pattern) []*Identifier {
+	switch pattern := pattern.(type) {
+	case *Identifier:
+		return []*Identifier{pattern}
+	case *ArrayPattern:
+		names := []*Identifier{}
+		for _, el := range pattern.Elements {
+			names = append(names, PatternNames(el.Name)...)
+		}
+		return names
+	case *HashPattern:
+		names := []*Identifier{}
+		for _, pair := range pattern.Pairs {
+			names = append(names, PatternNames(pair.Value.Name)...)
+		}
+		return names
+	}
+	return nil
+}
+
 func (fl *FunctionLiteral) expressionNode()      {}
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) Span() token.Span     { return spanToBlock(fl.Token, fl.Body) }
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
@@ -402,14 +615,61 @@ func (fl *FunctionLiteral) String() string {
 	return out.String()
 }
 
+// SpreadExpression is ...value, which unpacks an array, range or string
+// into a call or an array literal, or merges a hash into a hash literal
+type SpreadExpression struct {
+	Token token.Token // the ... token
+	Value Expression
+}
+
+func (se *SpreadExpression) expressionNode()      {}
+func (se *SpreadExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SpreadExpression) Span() token.Span     { return spanTo(se.Token, se.Value) }
+func (se *SpreadExpression) String() string {
+	if se.Value == nil {
+		return "..."
+	}
+	return "..." + se.Value.String()
+}
+
+// NamedArgument is a call argument given by name, like greeting: "sup"
+type NamedArgument struct {
+	Token token.Token // the name's token
+	Name  *Identifier
+	Value Expression
+}
+
+func (na *NamedArgument) expressionNode()      {}
+func (na *NamedArgument) TokenLiteral() string { return na.Token.Literal }
+func (na *NamedArgument) Span() token.Span     { return spanTo(na.Token, na.Value) }
+func (na *NamedArgument) String() string {
+	if na.Value == nil {
+		return na.Name.String() + ":"
+	}
+	return na.Name.String() + ": " + na.Value.String()
+}
+
 type CallExpression struct {
-	Token     token.Token // The '(' token
+	Token     token.Token // The '(' token, or the |> token for x |> f
 	Function  Expression  // Identifier or FunctionLiteral
 	Arguments []Expression
+	Rparen    token.Token // the ')' token, missing for x |> f
+	Piped     bool        // written as x |> f(...), with x as the first argument
 }
 
 func (ce *CallExpression) expressionNode()      {}
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Span() token.Span {
+	if !ce.Piped {
+		return token.Span{Start: startOf(ce.Function, ce.Token.Start), End: ce.Rparen.End}
+	}
+
+	end := ce.Rparen.End
+	if !end.IsValid() {
+		end = endOf(ce.Function, ce.Token.End)
+	}
+	return token.Span{Start: startOf(ce.Arguments[0], ce.Token.Start), End: end}
+}
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -418,6 +678,21 @@ func (ce *CallExpression) String() string {
 		args = append(args, a.String())
 	}
 
+	if ce.Piped {
+		out.WriteString("(")
+		out.WriteString(args[0])
+		out.WriteString(" |> ")
+		out.WriteString(ce.Function.String())
+		if ce.Rparen.Type == token.RPAREN {
+			out.WriteString("(")
+			out.WriteString(strings.Join(args[1:], ", "))
+			out.WriteString(")")
+		}
+		out.WriteString(")")
+
+		return out.String()
+	}
+
 	out.WriteString(ce.Function.String())
 	out.WriteString("(")
 	out.WriteString(strings.Join(args, ", "))
@@ -433,15 +708,50 @@ type StringLiteral struct {
 
 func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) Span() token.Span     { return sl.Token.Span() }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
 
+// InterpolatedString is a string with embedded expressions, e.g. "yo ${name}".
+// Parts holds the literal pieces as *StringLiteral in between the expressions.
+type InterpolatedString struct {
+	Token token.Token // the TEMPLATE_HEAD token
+	Parts []Expression
+	Tail  token.Token // the TEMPLATE_TAIL token
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) Span() token.Span {
+	return token.Span{Start: is.Token.Start, End: is.Tail.End}
+}
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	for _, part := range is.Parts {
+		if str, ok := part.(*StringLiteral); ok {
+			out.WriteString(strings.ReplaceAll(str.Value, "${", "\\${"))
+			continue
+		}
+
+		out.WriteString("${")
+		out.WriteString(part.String())
+		out.WriteString("}")
+	}
+
+	return out.String()
+}
+
 type ArrayLiteral struct {
 	Token    token.Token // the '[' token
 	Elements []Expression
+	Rbracket token.Token // the ']' token
 }
 
 func (al *ArrayLiteral) expressionNode()      {}
 func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) Span() token.Span {
+	return token.Span{Start: al.Token.Start, End: al.Rbracket.End}
+}
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 
@@ -458,18 +768,26 @@ func (al *ArrayLiteral) String() string {
 }
 
 type IndexExpression struct {
-	Token token.Token // The [ token
-	Left  Expression
-	Index Expression
+	Token    token.Token // The [ token
+	Left     Expression
+	Index    Expression
+	Rbracket token.Token // The ] token
+	Optional bool        // true for a?[k], which gives ghosted for the whole chain when a or a[k] is ghosted
 }
 
 func (ie *IndexExpression) expressionNode()      {}
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Span() token.Span {
+	return token.Span{Start: startOf(ie.Left, ie.Token.Start), End: ie.Rbracket.End}
+}
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteString("?")
+	}
 	out.WriteString("[")
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
@@ -477,19 +795,72 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// SliceExpression is a[start:end]. Both ends are included and either one
+// can be left out to go all the way to that end.
+type SliceExpression struct {
+	Token    token.Token // The [ token
+	Left     Expression
+	Start    Expression  // nil for a[:end]
+	End      Expression  // nil for a[start:]
+	Rbracket token.Token // The ] token
+	Optional bool        // true for a?[start:end]
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) Span() token.Span {
+	return token.Span{Start: startOf(se.Left, se.Token.Start), End: se.Rbracket.End}
+}
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	if se.Optional {
+		out.WriteString("?")
+	}
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// HashPair is a key: value pair in a hash literal, or a ...other that
+// merges another hash in, in which case Key and Value are nil
+type HashPair struct {
+	Key    Expression
+	Value  Expression
+	Spread *SpreadExpression
+}
+
 type HashLiteral struct {
-	Token token.Token // the '{' token
-	Pairs map[Expression]Expression
+	Token  token.Token // the '{' token
+	Pairs  []HashPair  // in the order they were written
+	Rbrace token.Token // the '}' token
 }
 
 func (hl *HashLiteral) expressionNode()      {}
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Span() token.Span {
+	return token.Span{Start: hl.Token.Start, End: hl.Rbrace.End}
+}
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, pair := range hl.Pairs {
+		if pair.Spread != nil {
+			pairs = append(pairs, pair.Spread.String())
+			continue
+		}
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
 	}
 
 	out.WriteString("{")
@@ -498,3 +869,31 @@ func (hl *HashLiteral) String() string {
 
 	return out.String()
 }
+
+// Helpers for computing spans of nodes whose children may be missing
+// after a failed parse
+
+func spanTo(start token.Token, end Node) token.Span {
+	return token.Span{Start: start.Start, End: endOf(end, start.End)}
+}
+
+func spanToBlock(start token.Token, block *BlockStatement) token.Span {
+	if block == nil {
+		return start.Span()
+	}
+	return token.Span{Start: start.Start, End: block.Span().End}
+}
+
+func startOf(n Node, fallback token.Position) token.Position {
+	if n == nil {
+		return fallback
+	}
+	return n.Span().Start
+}
+
+func endOf(n Node, fallback token.Position) token.Position {
+	if n == nil {
+		return fallback
+	}
+	return n.Span().End
+}
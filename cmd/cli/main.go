@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"nocap/diagnostic"
 	"nocap/evaluator"
 	"nocap/lexer"
 	"nocap/object"
@@ -13,9 +14,41 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Exit codes for `nocap run`, so shell scripts can tell a script that
+// didn't parse apart from one that blew up while running
+const (
+	exitRuntimeError  = 1
+	exitParseError    = 2
+	exitInternalError = 3  // the interpreter itself panicked
+	exitUsageError    = 64 // bad arguments or an unreadable file, like sysexits' EX_USAGE
+)
+
+// exitError makes the command exit with the given code. The problem has
+// already been printed by the time it is returned.
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// fail stops the command with the given exit code without cobra printing
+// the usage or the error again
+func fail(cmd *cobra.Command, code int) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return &exitError{code: code}
+}
+
 var executeCmd = &cobra.Command{
 	Use:   "run <file>",
 	Short: "Run a NoCap script",
+	Long: "Run a NoCap script.\n\n" +
+		"Exits with status 2 if the script doesn't parse (nothing in it is run),\n" +
+		"with status 1 if it fails while running, with status 3 if the\n" +
+		"interpreter itself breaks, and with status 64 if the file can't be run\n" +
+		"at all, like when it doesn't exist.",
 	Example: "Run a NoCap script from a file:\n" +
 		"$  nocap run script.nocap\n",
 	Args: func(cmd *cobra.Command, args []string) error {
@@ -41,39 +74,42 @@ var executeCmd = &cobra.Command{
 			return errors.New("failed to read the file: " + err.Error())
 		}
 
+		env := object.NewEnvironment()
+
 		defer func() {
 			if r := recover(); r != nil {
-				er = errors.New("this is awkward... something went very wrong and it's not your fault 😬")
+				printLogs(env)
+				fmt.Println("this is awkward... something went very wrong and it's not your fault 😬")
+				er = fail(cmd, exitInternalError)
 			}
 		}()
 
 		input := string(content)
-		l := lexer.New(input)
+		l := lexer.NewWithFilename(args[0], input)
 		p := parser.New(l)
-		env := object.NewEnvironment()
 
 		program := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			fmt.Printf("\033[31m\nError: %s\n\033[0m", p.Errors()[0])
-		}
-
-		evaluated := evaluator.Eval(program, env)
-
-		if len(env.Logs) > 0 {
+		if len(p.Diagnostics()) != 0 {
 			fmt.Println()
-			for _, log := range env.Logs {
-				fmt.Printf("\033[34m%s\033[0m\n", log)
+			for _, d := range p.Diagnostics() {
+				printDiagnostic(d)
 			}
+			return fail(cmd, exitParseError)
 		}
 
+		evaluated := evaluator.Eval(program, env)
+		printLogs(env)
+
 		if evaluated != nil {
 			switch res := evaluated.(type) {
 			case *object.Error:
-				fmt.Printf("\033[31m\nError: %s\n\033[0m", res.Inspect())
+				fmt.Println()
+				printDiagnostic(res.Diagnostic())
+				return fail(cmd, exitRuntimeError)
 			case *object.Null:
 				return nil
 			default:
-				result := evaluated.Inspect()
+				result := object.Pretty(evaluated, object.DefaultPrettyWidth)
 				fmt.Println("\n\033[32m" + result + "\033[0m")
 			}
 		}
@@ -82,6 +118,24 @@ var executeCmd = &cobra.Command{
 	},
 }
 
+// printLogs prints everything the script logged with caughtIn4K, in blue
+func printLogs(env *object.Environment) {
+	if len(env.Logs) > 0 {
+		fmt.Println()
+		for _, log := range env.Logs {
+			fmt.Printf("\033[34m%s\033[0m\n", log)
+		}
+	}
+}
+
+// printDiagnostic prints a problem in red, followed by any hints on how to fix it
+func printDiagnostic(d diagnostic.Diagnostic) {
+	fmt.Printf("\033[31mError[%s]: %s\n\033[0m", d.Code, d)
+	for _, hint := range d.Hints {
+		fmt.Printf("\033[33m  hint: %s\n\033[0m", hint)
+	}
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "nocap",
 	Short: "A programming language for GenZ",
@@ -91,7 +145,12 @@ func main() {
 	rootCmd.AddCommand(executeCmd)
 
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		var exit *exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
+
+		// anything else is a usage problem, which cobra has already printed
+		os.Exit(exitUsageError)
 	}
 }
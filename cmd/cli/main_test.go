@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain lets the tests run the real command in a child process, so they
+// can see the exit code main passes to os.Exit
+func TestMain(m *testing.M) {
+	if os.Getenv("NOCAP_RUN_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runNocap runs nocap with args and returns everything it printed along
+// with its exit code
+func runNocap(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "NOCAP_RUN_MAIN=1")
+	out, err := cmd.CombinedOutput()
+
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return string(out), exit.ExitCode()
+	} else if err != nil {
+		t.Fatalf("couldn't run the command: %v", err)
+	}
+	return string(out), 0
+}
+
+func TestRunExitCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		code     int
+		expected string
+	}{
+		{"parse error", "caughtIn4K(\"script ran\")\nfr = 5\n", exitParseError, "Error[unexpected-token]"},
+		{"runtime error", "caughtIn4K(\"script ran\")\nnope\n", exitRuntimeError, "Error[undefined-variable]"},
+		{"success", "caughtIn4K(\"script ran\")\n1 + 1\n", 0, "2"},
+	}
+
+	for _, tt := range tests {
+		file := filepath.Join(t.TempDir(), "script.nocap")
+		if err := os.WriteFile(file, []byte(tt.script), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		out, code := runNocap(t, "run", file)
+
+		if code != tt.code {
+			t.Errorf("%s: wrong exit code. expected=%d, got=%d\n%s", tt.name, tt.code, code, out)
+		}
+		if !strings.Contains(out, tt.expected) {
+			t.Errorf("%s: expected output to contain %q. got=%q", tt.name, tt.expected, out)
+		}
+
+		// a script that doesn't parse must not run at all
+		ran := strings.Contains(out, "script ran")
+		if ran != (tt.code != exitParseError) {
+			t.Errorf("%s: wrong evaluation. expected ran=%t, got=%t\n%s", tt.name, !ran, ran, out)
+		}
+	}
+}
+
+func TestRunUsageErrors(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"run", filepath.Join(t.TempDir(), "missing.nocap")}, "the specified file does not exist"},
+		{[]string{"run"}, "you need to provide exactly one file to run"},
+	}
+
+	for _, tt := range tests {
+		out, code := runNocap(t, tt.args...)
+
+		if code != exitUsageError {
+			t.Errorf("%q: wrong exit code. expected=%d, got=%d\n%s", tt.args, exitUsageError, code, out)
+		}
+		if n := strings.Count(out, tt.expected); n != 1 {
+			t.Errorf("%q: expected the error to be printed once, got %d times\n%s", tt.args, n, out)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"nocap/diagnostic"
 	"nocap/evaluator"
 	"nocap/lexer"
 	"nocap/object"
@@ -16,16 +17,17 @@ func main() {
 
 func ExecuteNoCap() js.Func {
 	return js.FuncOf(func(this js.Value, args []js.Value) (o any) {
-		output := func(result *string, errors []string, logs []string) string {
+		output := func(result *string, diagnostics []diagnostic.Diagnostic, logs []string) string {
 			var resultValue any
 			if result != nil {
 				resultValue = *result
 			}
 
 			x := map[string]any{
-				"result": resultValue,
-				"errors": errors,
-				"logs":   logs,
+				"result":      resultValue,
+				"errors":      diagnostic.Strings(diagnostics),
+				"diagnostics": diagnosticsToJS(diagnostics),
+				"logs":        logs,
 			}
 
 			jsonString, err := json.Marshal(x)
@@ -40,12 +42,12 @@ func ExecuteNoCap() js.Func {
 		defer func() {
 			if r := recover(); r != nil {
 				// Return error through output function
-				o = output(nil, []string{"This is awkward... something went very wrong and it's not your fault 😬!"}, []string{})
+				o = output(nil, []diagnostic.Diagnostic{{Message: "This is awkward... something went very wrong and it's not your fault 😬!"}}, []string{})
 			}
 		}()
 
 		if len(args) != 1 {
-			return output(nil, []string{"Invalid number of arguments. Expected 1 argument."}, []string{})
+			return output(nil, []diagnostic.Diagnostic{{Message: "Invalid number of arguments. Expected 1 argument."}}, []string{})
 		}
 
 		input := args[0].String()
@@ -54,28 +56,47 @@ func ExecuteNoCap() js.Func {
 		env := object.NewEnvironment()
 
 		program := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			errors := make([]string, 0, len(p.Errors()))
-			for _, msg := range p.Errors() {
-				errors = append(errors, msg)
-			}
-
-			return output(nil, errors, []string{})
+		if len(p.Diagnostics()) != 0 {
+			return output(nil, p.Diagnostics(), []string{})
 		}
 
 		evaluated := evaluator.Eval(program, env)
 		if evaluated != nil {
 			switch res := evaluated.(type) {
 			case *object.Error:
-				return output(nil, []string{res.Inspect()}, env.Logs)
+				return output(nil, []diagnostic.Diagnostic{res.Diagnostic()}, env.Logs)
 			case *object.Null:
-				return output(nil, []string{}, env.Logs)
+				return output(nil, nil, env.Logs)
 			default:
 				result := evaluated.Inspect()
-				return output(&result, []string{}, env.Logs)
+				return output(&result, nil, env.Logs)
 			}
 		} else {
-			return output(nil, []string{}, env.Logs)
+			return output(nil, nil, env.Logs)
 		}
 	})
 }
+
+// diagnosticsToJS turns diagnostics into plain maps, so the editor can
+// underline the exact span and show the code and hints next to it
+func diagnosticsToJS(diagnostics []diagnostic.Diagnostic) []map[string]any {
+	out := make([]map[string]any, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		hints := d.Hints
+		if hints == nil {
+			hints = []string{}
+		}
+
+		out = append(out, map[string]any{
+			"severity": d.Severity.String(),
+			"code":     d.Code,
+			"message":  d.Message,
+			"start":    map[string]int{"line": d.Span.Start.Line, "column": d.Span.Start.Column},
+			"end":      map[string]int{"line": d.Span.End.Line, "column": d.Span.End.Column},
+			"expected": d.Expected,
+			"actual":   d.Actual,
+			"hints":    hints,
+		})
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package diagnostic
+
+import (
+	"fmt"
+	"nocap/token"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Info
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	case Info:
+		return "info"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// Code identifies a kind of problem. Codes never change once they are
+// released, so tools can match on them instead of on the message text
+type Code string
+
+// Problems found while reading characters
+const (
+	UnknownCharacter    Code = "unknown-character"
+	MalformedNumber     Code = "malformed-number"
+	InvalidEscape       Code = "invalid-escape"
+	UnterminatedString  Code = "unterminated-string"
+	UnterminatedComment Code = "unterminated-comment"
+)
+
+// Problems found while parsing
+const (
+	UnexpectedToken           Code = "unexpected-token"
+	MissingExpression         Code = "missing-expression"
+	InvalidAssignmentTarget   Code = "invalid-assignment-target"
+	InvalidParameter          Code = "invalid-parameter"
+	InvalidPattern            Code = "invalid-pattern"
+	InvalidArgumentOrder      Code = "invalid-argument-order"
+	MisplacedSpread           Code = "misplaced-spread"
+	NumberOutOfRange          Code = "number-out-of-range"
+	EmptyInterpolation        Code = "empty-interpolation"
+	UnterminatedInterpolation Code = "unterminated-interpolation"
+)
+
+// Problems found while running
+const (
+	TypeMismatch           Code = "type-mismatch"
+	UnknownOperator        Code = "unknown-operator"
+	DivisionByZero         Code = "division-by-zero"
+	IntegerOverflow        Code = "integer-overflow"
+	InvalidArgument        Code = "invalid-argument"
+	WrongArgumentCount     Code = "wrong-argument-count"
+	UndefinedVariable      Code = "undefined-variable"
+	ConstantReassignment   Code = "constant-reassignment"
+	NotCallable            Code = "not-callable"
+	NotIterable            Code = "not-iterable"
+	InvalidIndex           Code = "invalid-index"
+	IndexOutOfRange        Code = "index-out-of-range"
+	UnhashableKey          Code = "unhashable-key"
+	LoopControlOutsideLoop Code = "loop-control-outside-loop"
+)
+
+// Diagnostic is a single problem found in a script, by the lexer, the
+// parser or the evaluator
+type Diagnostic struct {
+	Severity Severity
+	Code     Code
+	Span     token.Span // where the problem is, if known
+	Message  string
+
+	// Expected and Actual are filled in when the parser wanted one token
+	// and found another, e.g. Expected ")" and Actual "}"
+	Expected string
+	Actual   string
+
+	// Hints are extra suggestions on how to fix the problem
+	Hints []string
+}
+
+// String formats the diagnostic as "file:line:col: message", leaving out
+// the position when there isn't one
+func (d Diagnostic) String() string {
+	if d.Span.Start.IsValid() {
+		return fmt.Sprintf("%s: %s", d.Span.Start, d.Message)
+	}
+	return d.Message
+}
+
+// Strings formats each diagnostic with String
+func Strings(diagnostics []Diagnostic) []string {
+	out := make([]string, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		out = append(out, d.String())
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package diagnostic
+
+import (
+	"nocap/token"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		diagnostic Diagnostic
+		expected   string
+	}{
+		{
+			Diagnostic{
+				Code:    UnexpectedToken,
+				Span:    token.Span{Start: token.Position{File: "main.nocap", Line: 3, Column: 7}},
+				Message: "bruh",
+			},
+			"main.nocap:3:7: bruh",
+		},
+		{
+			Diagnostic{
+				Code:    DivisionByZero,
+				Span:    token.Span{Start: token.Position{Line: 1, Column: 2}},
+				Message: "nope",
+			},
+			"1:2: nope",
+		},
+		{
+			Diagnostic{Code: TypeMismatch, Message: "somewhere"},
+			"somewhere",
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.diagnostic.String() != tt.expected {
+			t.Errorf("String() wrong. expected=%q, got=%q", tt.expected, tt.diagnostic.String())
+		}
+	}
+}
+
+func TestSeverityString(t *testing.T) {
+	tests := []struct {
+		severity Severity
+		expected string
+	}{
+		{Error, "error"},
+		{Warning, "warning"},
+		{Info, "info"},
+		{Severity(7), "severity(7)"},
+	}
+
+	for _, tt := range tests {
+		if tt.severity.String() != tt.expected {
+			t.Errorf("String() wrong. expected=%q, got=%q", tt.expected, tt.severity.String())
+		}
+	}
+}
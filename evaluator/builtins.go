@@ -1,24 +1,29 @@
 package evaluator
 
 import (
+	"math"
+	"nocap/diagnostic"
 	"nocap/object"
+	"unicode/utf8"
 )
 
 var builtins = map[string]*object.Builtin{
 	"count": &object.Builtin{Fn: func(args ...object.Object) object.Object {
 		if len(args) != 1 {
-			return newError("count needs 1 argument but you gave it %d 🥲", len(args))
+			return newError(diagnostic.WrongArgumentCount, "count needs 1 argument but you gave it %d 🥲", len(args))
 		}
 
 		switch arg := args[0].(type) {
 		case *object.Array:
 			return &object.Integer{Value: int64(len(arg.Elements))}
 		case *object.String:
-			return &object.Integer{Value: int64(len(arg.Value))}
+			return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 		case *object.Hash:
-			return &object.Integer{Value: int64(len(arg.Pairs))}
+			return &object.Integer{Value: int64(arg.Len())}
+		case *object.Range:
+			return &object.Integer{Value: arg.Len()}
 		default:
-			return newError("count can only be used with arrays, strings, or hashes, not %s 🙄", arg.Type())
+			return newError(diagnostic.TypeMismatch, "count can only be used with arrays, strings, hashes, or ranges, not %s 🙄", arg.Type())
 		}
 	},
 		Name: "count",
@@ -36,10 +41,10 @@ var builtins = map[string]*object.Builtin{
 	"slide": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 2 {
-				return newError("slide needs 2 arguments but you gave it %d 🥲", len(args))
+				return newError(diagnostic.WrongArgumentCount, "slide needs 2 arguments but you gave it %d 🥲", len(args))
 			}
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("slide needs an array to work with, not %s - can't slide on that! 🛝", args[0].Type())
+				return newError(diagnostic.TypeMismatch, "slide needs an array to work with, not %s - can't slide on that! 🛝", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
@@ -56,18 +61,27 @@ var builtins = map[string]*object.Builtin{
 	"spread": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) > 2 || len(args) < 1 {
-				return newError("spread needs 1 or 2 arguments but you gave it %d 🥲", len(args))
+				return newError(diagnostic.WrongArgumentCount, "spread needs 1 or 2 arguments but you gave it %d 🥲", len(args))
+			}
+
+			if rng, ok := args[0].(*object.Range); ok && len(args) == 1 {
+				elements, err := rangeElements(rng)
+				if err != nil {
+					return err
+				}
+
+				return &object.Array{Elements: elements}
 			}
 
 			if len(args) == 1 {
 				if args[0].Type() != object.STRING_OBJ {
-					return newError("spread expected a string but got %s - can't spread that 🧈", args[0].Type())
+					return newError(diagnostic.TypeMismatch, "spread expected a string but got %s - can't spread that 🧈", args[0].Type())
 				}
 
 				// Split the string into characters
-				str := args[0].(*object.String).Value
-				elements := make([]object.Object, len(str))
-				for i, char := range str {
+				chars := []rune(args[0].(*object.String).Value)
+				elements := make([]object.Object, len(chars))
+				for i, char := range chars {
 					elements[i] = &object.String{Value: string(char)}
 				}
 
@@ -75,14 +89,14 @@ var builtins = map[string]*object.Builtin{
 			}
 
 			if args[0].Type() != object.INTEGER_OBJ || args[1].Type() != object.INTEGER_OBJ {
-				return newError("spread needs two whole numbers, not %s and %s - those two don't make a range", args[0].Type(), args[1].Type())
+				return newError(diagnostic.TypeMismatch, "spread needs two whole numbers, not %s and %s - those two don't make a range", args[0].Type(), args[1].Type())
 			}
 
 			start := args[0].(*object.Integer).Value
 			end := args[1].(*object.Integer).Value
 
 			if start > end {
-				return newError("spread(%d, %d)? That's backwards - start cannot be greater than the end", start, end)
+				return newError(diagnostic.InvalidArgument, "spread(%d, %d)? That's backwards - start cannot be greater than the end", start, end)
 			}
 
 			length := end - start + 1
@@ -95,4 +109,26 @@ var builtins = map[string]*object.Builtin{
 		},
 		Name: "spread",
 	},
+	"glowUp": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 2 || len(args) < 1 {
+				return newError(diagnostic.WrongArgumentCount, "glowUp needs 1 or 2 arguments but you gave it %d 🥲", len(args))
+			}
+
+			width := int64(object.DefaultPrettyWidth)
+			if len(args) == 2 {
+				w, ok := args[1].(*object.Integer)
+				if !ok {
+					return newError(diagnostic.TypeMismatch, "glowUp needs a whole number for the width, not %s 📏", args[1].Type())
+				}
+				if w.Value < 1 {
+					return newError(diagnostic.InvalidArgument, "glowUp can't fit anything in a width of %d 📏", w.Value)
+				}
+				width = w.Value
+			}
+
+			return &object.String{Value: object.Pretty(args[0], int(min(width, math.MaxInt32)))}
+		},
+		Name: "glowUp",
+	},
 }
@@ -1,10 +1,14 @@
 package evaluator
 
 import (
+	"cmp"
 	"fmt"
 	"math"
 	"nocap/ast"
+	"nocap/diagnostic"
 	"nocap/object"
+	"strings"
+	"unicode/utf8"
 )
 
 var (
@@ -14,6 +18,18 @@ var (
 )
 
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	return tagError(eval(node, env), node)
+}
+
+// tagError tags errors with the innermost node that produced them
+func tagError(result object.Object, node ast.Node) object.Object {
+	if err, ok := result.(*object.Error); ok && !err.Span.Start.IsValid() {
+		err.Span = node.Span()
+	}
+	return result
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 
 	// Statements
@@ -21,7 +37,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalProgram(node, env)
 
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
+		// every block gets its own scope, so fr inside a vibe branch
+		// doesn't leak out of it
+		return evalBlockStatement(node, object.NewEnclosedEnvironment(env))
 
 	case *ast.ExpressionStatement:
 		return Eval(node.Expression, env)
@@ -38,19 +56,16 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
-
-	case *ast.AssignmentStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
-			return val
+		set := func(name string, val object.Object) object.Object {
+			return declare(env, name, val, node.Constant)
 		}
-
-		obj := env.Update(node.Name.Value, val)
-		if isError(obj) {
-			return obj
+		if res := bindPattern(node.Name, val, env, set); isError(res) {
+			return res
 		}
 
+	case *ast.AssignmentStatement:
+		return evalAssignmentStatement(node, env)
+
 	case *ast.IndexExpressionAssignmentStatement:
 		item := Eval(node.Left.Left, env)
 		if isError(item) {
@@ -58,7 +73,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 
 		if item.Type() != object.ARRAY_OBJ && item.Type() != object.HASH_OBJ {
-			return newError("seriously what are you trying to do here? [] can't be used with items of type %s 🙄", item.Type())
+			return newError(diagnostic.InvalidIndex, "seriously what are you trying to do here? [] can't be used with items of type %s 🙄", item.Type())
 		}
 
 		index := Eval(node.Left.Index, env)
@@ -71,6 +86,18 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return val
 		}
 
+		if node.Operator != "" {
+			current := evalIndexExpression(item, index)
+			if isError(current) {
+				return current
+			}
+
+			val = evalInfixExpression(node.Operator, current, val)
+			if isError(val) {
+				return val
+			}
+		}
+
 		return evalIndexExpressionAssignmentStatement(item, index, val)
 
 	case *ast.ForStatement:
@@ -90,7 +117,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		body := node.Body
 		fn := &object.Function{Parameters: params, Env: env, Body: body}
 
-		env.Set(node.Name.Value, fn)
+		if res := declare(env, node.Name.Value, fn, node.Constant); isError(res) {
+			return res
+		}
 
 	// Expressions
 	case *ast.IntegerLiteral:
@@ -102,6 +131,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
+
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 
@@ -125,6 +157,10 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return evalBooleanInfixExpression(node.Operator, left, node.Right, env)
 		}
 
+		if node.Operator == "??" {
+			return evalCoalesceExpression(left, node.Right, env)
+		}
+
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
@@ -144,17 +180,8 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return &object.Function{Parameters: params, Env: env, Body: body}
 
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
-			return function
-		}
-
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-
-		return applyFunction(function, args, env)
+		result, _ := evalChain(node, env)
+		return result
 
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
@@ -164,19 +191,258 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return &object.Array{Elements: elements}
 
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
+		result, _ := evalChain(node, env)
+		return result
+
+	case *ast.SliceExpression:
+		result, _ := evalChain(node, env)
+		return result
+
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
+
+	case *ast.RangeExpression:
+		return evalRangeExpression(node, env)
+
+	}
+
+	return nil
+}
+
+// evalChain evaluates a chain of calls, indexes and slices like a?[1][2](3).
+// Once a ?[ finds ghosted, either before it or as what it grabbed, the rest
+// of the chain is skipped and the whole chain gives ghosted. The bool tells
+// the links after it to skip themselves.
+func evalChain(node ast.Expression, env *object.Environment) (object.Object, bool) {
+	result, skipped := evalChainLink(node, env)
+	return tagError(result, node), skipped
+}
+
+func evalChainLink(node ast.Expression, env *object.Environment) (object.Object, bool) {
+	switch node := node.(type) {
+	case *ast.CallExpression:
+		function, skipped := evalChain(node.Function, env)
+		if skipped {
+			return NULL, true
+		}
+		if isError(function) {
+			return function, false
+		}
+
+		args, named, err := evalArguments(node.Arguments, env)
+		if err != nil {
+			return err, false
+		}
+
+		return applyFunction(function, args, named, env), false
+
+	case *ast.IndexExpression:
+		left, skipped := evalChain(node.Left, env)
+		if skipped {
+			return NULL, true
+		}
 		if isError(left) {
-			return left
+			return left, false
+		}
+		if node.Optional && left == NULL {
+			return NULL, true
 		}
 		index := Eval(node.Index, env)
 		if isError(index) {
-			return index
+			return index, false
 		}
-		return evalIndexExpression(left, index)
+		result := evalIndexExpression(left, index)
+		return result, node.Optional && result == NULL
 
-	case *ast.HashLiteral:
-		return evalHashLiteral(node, env)
+	case *ast.SliceExpression:
+		left, skipped := evalChain(node.Left, env)
+		if skipped {
+			return NULL, true
+		}
+		if isError(left) {
+			return left, false
+		}
+		if node.Optional && left == NULL {
+			return NULL, true
+		}
+		return evalSliceExpression(node, left, env), false
+	}
+
+	return Eval(node, env), false
+}
+
+// declare binds name in env, as a constant for deadass declarations
+func declare(env *object.Environment, name string, val object.Object, constant bool) object.Object {
+	if constant {
+		return env.SetConstant(name, val)
+	}
+	return env.Set(name, val)
+}
+
+// bindPattern binds value to pattern with set, unpacking it into the names
+// of an [a, b] or {a, b} pattern. Items that are missing from the value get
+// their default, worked out in env, or ghosted when they don't have one.
+func bindPattern(
+	pattern ast.Pattern,
+	value object.Object,
+	env *object.Environment,
+	set func(name string, val object.Object) object.Object,
+) object.Object {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		return set(pattern.Value, value)
+	case *ast.ArrayPattern:
+		return bindArrayPattern(pattern, value, env, set)
+	case *ast.HashPattern:
+		return bindHashPattern(pattern, value, env, set)
+	}
+	return value
+}
+
+// bindElement binds one item of a pattern, where a nil value means the
+// item is missing
+func bindElement(
+	el *ast.Parameter,
+	value object.Object,
+	env *object.Environment,
+	set func(name string, val object.Object) object.Object,
+) object.Object {
+	if value == nil {
+		value = NULL
+		if el.Default != nil {
+			value = Eval(el.Default, env)
+			if isError(value) {
+				return value
+			}
+		}
+	}
+	return bindPattern(el.Name, value, env, set)
+}
+
+func bindArrayPattern(
+	pattern *ast.ArrayPattern,
+	value object.Object,
+	env *object.Environment,
+	set func(name string, val object.Object) object.Object,
+) object.Object {
+	// at returns the i-th item, so ranges never have to be turned into
+	// arrays unless there's a ...rest
+	var n int64
+	var at func(i int64) object.Object
+
+	switch value := value.(type) {
+	case *object.Array:
+		n = int64(len(value.Elements))
+		at = func(i int64) object.Object { return value.Elements[i] }
+	case *object.Range:
+		n = value.Len()
+		at = func(i int64) object.Object { return &object.Integer{Value: value.At(i)} }
+	case *object.String:
+		chars := []rune(value.Value)
+		n = int64(len(chars))
+		at = func(i int64) object.Object { return &object.String{Value: string(chars[i])} }
+	default:
+		return newErrorAt(pattern, diagnostic.TypeMismatch, "can't unpack a %s into %s - only arrays, ranges and strings fit in a [...] pattern 📦", value.Type(), pattern)
+	}
+
+	for i, el := range pattern.Elements {
+		var item object.Object
+		switch {
+		case el.Rest:
+			rest := []object.Object{}
+			if r, ok := value.(*object.Range); ok && int64(i) < n {
+				var err *object.Error
+				rest, err = rangeElements(&object.Range{Start: r.At(int64(i)), End: r.At(n - 1), Step: r.Step})
+				if err != nil {
+					return err
+				}
+			} else {
+				for j := int64(i); j < n; j++ {
+					rest = append(rest, at(j))
+				}
+			}
+			item = &object.Array{Elements: rest}
+		case int64(i) < n:
+			item = at(int64(i))
+		}
+
+		if res := bindElement(el, item, env, set); isError(res) {
+			return res
+		}
+	}
+
+	return value
+}
+
+func bindHashPattern(
+	pattern *ast.HashPattern,
+	value object.Object,
+	env *object.Environment,
+	set func(name string, val object.Object) object.Object,
+) object.Object {
+	hash, ok := value.(*object.Hash)
+	if !ok {
+		return newErrorAt(pattern, diagnostic.TypeMismatch, "can't unpack a %s into %s - only hashes fit in a {...} pattern 📦", value.Type(), pattern)
+	}
+
+	taken := map[string]bool{}
+	for _, pair := range pattern.Pairs {
+		var item object.Object
+
+		if pair.Value.Rest {
+			// ...rest gets every pair that wasn't asked for by name
+			rest := object.NewHash()
+			for _, p := range hash.Pairs() {
+				if key, ok := p.Key.(*object.String); ok && taken[key.Value] {
+					continue
+				}
+				rest.Set(p.Key.(object.Hashable), p.Value)
+			}
+			item = rest
+		} else {
+			taken[pair.Key.Value] = true
+			if p, ok := hash.Get(&object.String{Value: pair.Key.Value}); ok {
+				item = p.Value
+			}
+		}
 
+		if res := bindElement(pair.Value, item, env, set); isError(res) {
+			return res
+		}
+	}
+
+	return value
+}
+
+func evalAssignmentStatement(
+	node *ast.AssignmentStatement,
+	env *object.Environment,
+) object.Object {
+	var current object.Object
+	if node.Operator != "" {
+		var ok bool
+		current, ok = env.Get(node.Name.Value)
+		if !ok {
+			// Update reports the missing definition the same way a plain = does
+			return env.Update(node.Name.Value, NULL)
+		}
+	}
+
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	if node.Operator != "" {
+		val = evalInfixExpression(node.Operator, current, val)
+		if isError(val) {
+			return val
+		}
+	}
+
+	obj := env.Update(node.Name.Value, val)
+	if isError(obj) {
+		return obj
 	}
 
 	return nil
@@ -192,9 +458,9 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 		case *object.ReturnValue:
 			return result.Value
 		case *object.Break:
-			return newError("hey! you can't just bounce outside of a loop 🫠")
+			return newErrorAt(statement, diagnostic.LoopControlOutsideLoop, "hey! you can't just bounce outside of a loop 🫠")
 		case *object.Continue:
-			return newError("hey! you can't just pass outside of a loop 🫠")
+			return newErrorAt(statement, diagnostic.LoopControlOutsideLoop, "hey! you can't just pass outside of a loop 🫠")
 		case *object.Error:
 			return result
 		}
@@ -236,8 +502,10 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 		return evalBangOperatorExpression(right)
 	case "-":
 		return evalMinusPrefixOperatorExpression(right)
+	case "~":
+		return evalBitNotPrefixOperatorExpression(right)
 	default:
-		return newError("what the hell is this? %s%s 🐘🐧", operator, right.Type())
+		return newError(diagnostic.UnknownOperator, "what the hell is this? %s%s 🐘🐧", operator, right.Type())
 	}
 }
 
@@ -248,6 +516,9 @@ func evalInfixExpression(
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isBitwiseOperator(operator):
+		return newError(diagnostic.TypeMismatch, "%s only works on whole numbers, but you gave it a %s and a %s 🔢",
+			operator, left.Type(), right.Type())
 	case (left.Type() == object.INTEGER_OBJ || left.Type() == object.FLOAT_OBJ) && (right.Type() == object.INTEGER_OBJ || right.Type() == object.FLOAT_OBJ):
 		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
@@ -256,14 +527,16 @@ func evalInfixExpression(
 		((left.Type() == object.INTEGER_OBJ || left.Type() == object.FLOAT_OBJ) && right.Type() == object.STRING_OBJ):
 		return evalStringAndNumberInfixExpression(operator, left, right)
 	case operator == "is":
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(object.Equal(left, right))
 	case operator == "aint":
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!object.Equal(left, right))
+	case left.Type() == object.ARRAY_OBJ && right.Type() == object.ARRAY_OBJ:
+		return evalArrayInfixExpression(operator, left, right)
 	case left.Type() != right.Type():
-		return newError("what the hell is %s supposed to do between a %s and a %s 🐘🐧",
+		return newError(diagnostic.TypeMismatch, "what the hell is %s supposed to do between a %s and a %s 🐘🐧",
 			operator, left.Type(), right.Type())
 	default:
-		return newError("idk how to %s a %s with a %s 😬",
+		return newError(diagnostic.TypeMismatch, "idk how to %s a %s with a %s 😬",
 			operator, left.Type(), right.Type())
 	}
 }
@@ -279,12 +552,30 @@ func evalStringAndNumberInfixExpression(
 		} else {
 			return &object.String{Value: left.Inspect() + right.(*object.String).Value}
 		}
+	case "is":
+		return FALSE
+	case "aint":
+		return TRUE
 	default:
-		return newError("idk how to %s a %s with a %s 😬",
+		return newError(diagnostic.TypeMismatch, "idk how to %s a %s with a %s 😬",
 			operator, left.Type(), right.Type())
 	}
 }
 
+// evalCoalesceExpression only evaluates the right side when the left one
+// is ghosted, so a ?? expensive() doesn't do any extra work
+func evalCoalesceExpression(
+	left object.Object,
+	rightNode ast.Expression,
+	env *object.Environment,
+) object.Object {
+	if left != NULL {
+		return left
+	}
+
+	return Eval(rightNode, env)
+}
+
 func evalBooleanInfixExpression(
 	operator string,
 	left object.Object,
@@ -308,7 +599,7 @@ func evalBooleanInfixExpression(
 		right := Eval(rightNode, env)
 		return nativeBoolToBooleanObject(isTruthy(left) || isTruthy(right))
 	default:
-		return newError("invalid operation")
+		return newError(diagnostic.UnknownOperator, "invalid operation")
 	}
 }
 
@@ -329,12 +620,32 @@ func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 	switch right.Type() {
 	case object.INTEGER_OBJ:
 		value := right.(*object.Integer).Value
+		if value == math.MinInt64 {
+			return newError(diagnostic.IntegerOverflow, "-(%d) is too big for a 64 bit integer 🤯", value)
+		}
 		return &object.Integer{Value: -value}
 	case object.FLOAT_OBJ:
 		value := right.(*object.Float).Value
 		return &object.Float{Value: -value}
 	default:
-		return newError("idk how to: -%s 😬", right.Type())
+		return newError(diagnostic.TypeMismatch, "idk how to: -%s 😬", right.Type())
+	}
+}
+
+func evalBitNotPrefixOperatorExpression(right object.Object) object.Object {
+	if right.Type() != object.INTEGER_OBJ {
+		return newError(diagnostic.TypeMismatch, "~ only works on whole numbers, but you gave it a %s 🔢", right.Type())
+	}
+
+	return &object.Integer{Value: ^right.(*object.Integer).Value}
+}
+
+func isBitwiseOperator(operator string) bool {
+	switch operator {
+	case "&", "|", "^", "<<", ">>":
+		return true
+	default:
+		return false
 	}
 }
 
@@ -346,15 +657,11 @@ func evalIntegerInfixExpression(
 	rightVal := right.(*object.Integer).Value
 
 	switch operator {
-	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
-	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
-	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+	case "+", "-", "*", "**", "~/":
+		return evalIntegerArithmetic(operator, leftVal, rightVal)
 	case "/":
 		if rightVal == 0 {
-			return newError("my math teacher said no dividing by zero! 😤")
+			return newError(diagnostic.DivisionByZero, "my math teacher said no dividing by zero! 😤")
 		}
 		// For / always return a float
 		return &object.Float{Value: float64(leftVal) / float64(rightVal)}
@@ -368,19 +675,130 @@ func evalIntegerInfixExpression(
 		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "%":
 		if rightVal == 0 {
-			return newError("my math teacher said no dividing by zero! 😤")
+			return newError(diagnostic.DivisionByZero, "my math teacher said no dividing by zero! 😤")
 		}
 		return &object.Integer{Value: leftVal % rightVal}
+	case "&":
+		return &object.Integer{Value: leftVal & rightVal}
+	case "|":
+		return &object.Integer{Value: leftVal | rightVal}
+	case "^":
+		return &object.Integer{Value: leftVal ^ rightVal}
+	case "<<", ">>":
+		if rightVal < 0 {
+			return newError(diagnostic.InvalidArgument, "can't shift by %d, the shift amount can't be negative 🙅", rightVal)
+		}
+		if operator == "<<" {
+			// shifting back must give the number we started with, or bits fell off the end
+			result := leftVal << rightVal
+			if result>>rightVal != leftVal {
+				return newError(diagnostic.IntegerOverflow, "%d << %d is too big for a 64 bit integer 🤯", leftVal, rightVal)
+			}
+			return &object.Integer{Value: result}
+		}
+		// >> only ever drops bits off the bottom, so it can't overflow
+		return &object.Integer{Value: leftVal >> rightVal}
 	case "is":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "aint":
 		return nativeBoolToBooleanObject(leftVal != rightVal)
 	default:
-		return newError("idk how to %s a %s with a %s 😬",
+		return newError(diagnostic.TypeMismatch, "idk how to %s a %s with a %s 😬",
 			operator, left.Type(), right.Type())
 	}
 }
 
+// evalIntegerArithmetic applies the operators that keep whole numbers whole,
+// reporting an error instead of silently wrapping around on overflow
+func evalIntegerArithmetic(operator string, left, right int64) object.Object {
+	var result int64
+	var ok bool
+
+	switch operator {
+	case "+":
+		result, ok = addInt64(left, right)
+	case "-":
+		result, ok = subInt64(left, right)
+	case "*":
+		result, ok = mulInt64(left, right)
+	case "**":
+		if right < 0 {
+			// negative powers are fractions, so they can't stay whole numbers
+			if left == 0 {
+				return newError(diagnostic.DivisionByZero, "my math teacher said no dividing by zero! 😤")
+			}
+			return &object.Float{Value: math.Pow(float64(left), float64(right))}
+		}
+		result, ok = powInt64(left, right)
+	case "~/":
+		if right == 0 {
+			return newError(diagnostic.DivisionByZero, "my math teacher said no dividing by zero! 😤")
+		}
+		result, ok = floorDivInt64(left, right)
+	}
+
+	if !ok {
+		return newError(diagnostic.IntegerOverflow, "%d %s %d is too big for a 64 bit integer 🤯", left, operator, right)
+	}
+
+	return &object.Integer{Value: result}
+}
+
+// The helpers below do integer math and report false when the result
+// doesn't fit in an int64
+
+func addInt64(a, b int64) (int64, bool) {
+	c := a + b
+	return c, (c > a) == (b > 0)
+}
+
+func subInt64(a, b int64) (int64, bool) {
+	c := a - b
+	return c, (c < a) == (b > 0)
+}
+
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return c, false
+	}
+	return c, c/b == a
+}
+
+func powInt64(base, exp int64) (int64, bool) {
+	result := int64(1)
+	for exp > 0 {
+		var ok bool
+		if exp&1 == 1 {
+			if result, ok = mulInt64(result, base); !ok {
+				return result, false
+			}
+		}
+		exp >>= 1
+		if exp > 0 {
+			if base, ok = mulInt64(base, base); !ok {
+				return base, false
+			}
+		}
+	}
+	return result, true
+}
+
+// floorDivInt64 divides rounding towards negative infinity, so -7 ~/ 2 is -4
+func floorDivInt64(a, b int64) (int64, bool) {
+	if a == math.MinInt64 && b == -1 {
+		return a, false
+	}
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q -= 1
+	}
+	return q, true
+}
+
 func evalFloatInfixExpression(
 	operator string,
 	left, right object.Object,
@@ -406,12 +824,24 @@ func evalFloatInfixExpression(
 		return &object.Float{Value: leftVal - rightVal}
 	case "*":
 		return &object.Float{Value: leftVal * rightVal}
+	case "**":
+		// a negative power of 0 is 1 divided by 0
+		if leftVal == 0 && rightVal < 0 {
+			return newError(diagnostic.DivisionByZero, "my math teacher said no dividing by zero! 😤")
+		}
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
 	case "/":
 		if rightVal == 0 {
-			return newError("my math teacher said no dividing by zero! 😤")
+			return newError(diagnostic.DivisionByZero, "my math teacher said no dividing by zero! 😤")
 		}
 
 		return &object.Float{Value: leftVal / rightVal}
+	case "~/":
+		if rightVal == 0 {
+			return newError(diagnostic.DivisionByZero, "my math teacher said no dividing by zero! 😤")
+		}
+
+		return &object.Float{Value: math.Floor(leftVal / rightVal)}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
@@ -422,7 +852,7 @@ func evalFloatInfixExpression(
 		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "%":
 		if rightVal == 0 {
-			return newError("my math teacher said no dividing by zero! 😤")
+			return newError(diagnostic.DivisionByZero, "my math teacher said no dividing by zero! 😤")
 		}
 		return &object.Float{Value: math.Mod(leftVal, rightVal)}
 	case "is":
@@ -430,7 +860,7 @@ func evalFloatInfixExpression(
 	case "aint":
 		return nativeBoolToBooleanObject(leftVal != rightVal)
 	default:
-		return newError("idk how to %s a %s with a %s 😬",
+		return newError(diagnostic.TypeMismatch, "idk how to %s a %s with a %s 😬",
 			operator, left.Type(), right.Type())
 	}
 }
@@ -449,12 +879,124 @@ func evalStringInfixExpression(
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "aint":
 		return nativeBoolToBooleanObject(leftVal != rightVal)
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	default:
-		return newError("idk how to %s a %s with a %s 😬",
+		return newError(diagnostic.TypeMismatch, "idk how to %s a %s with a %s 😬",
 			operator, left.Type(), right.Type())
 	}
 }
 
+// evalArrayInfixExpression orders arrays the way a dictionary orders words:
+// by the first element that differs, and a shorter array comes first when
+// it runs out before anything differs
+func evalArrayInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	switch operator {
+	case "<", ">", "<=", ">=":
+	default:
+		return newError(diagnostic.TypeMismatch, "idk how to %s a %s with a %s 😬",
+			operator, left.Type(), right.Type())
+	}
+
+	result, err := compareObjects(operator, left, right, map[[2]object.Object]bool{})
+	if err != nil {
+		return err
+	}
+
+	switch operator {
+	case "<":
+		return nativeBoolToBooleanObject(result < 0)
+	case ">":
+		return nativeBoolToBooleanObject(result > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(result <= 0)
+	default:
+		return nativeBoolToBooleanObject(result >= 0)
+	}
+}
+
+// compareObjects returns -1, 0 or 1 when left is less than, equal to or
+// greater than right. Numbers, strings and arrays of them can be put in
+// order, anything else is an error. seen stops an array that contains
+// itself from being compared forever.
+func compareObjects(
+	operator string,
+	left, right object.Object,
+	seen map[[2]object.Object]bool,
+) (int, *object.Error) {
+	switch left := left.(type) {
+	case *object.Integer:
+		switch right := right.(type) {
+		case *object.Integer:
+			return cmp.Compare(left.Value, right.Value), nil
+		case *object.Float:
+			return cmp.Compare(float64(left.Value), right.Value), nil
+		}
+	case *object.Float:
+		switch right := right.(type) {
+		case *object.Integer:
+			return cmp.Compare(left.Value, float64(right.Value)), nil
+		case *object.Float:
+			return cmp.Compare(left.Value, right.Value), nil
+		}
+	case *object.String:
+		if right, ok := right.(*object.String); ok {
+			return strings.Compare(left.Value, right.Value), nil
+		}
+	case *object.Array:
+		right, ok := right.(*object.Array)
+		if !ok {
+			break
+		}
+		if seen[[2]object.Object{left, right}] {
+			return 0, nil
+		}
+		seen[[2]object.Object{left, right}] = true
+
+		for i := 0; i < len(left.Elements) && i < len(right.Elements); i++ {
+			result, err := compareObjects(operator, left.Elements[i], right.Elements[i], seen)
+			if err != nil || result != 0 {
+				return result, err
+			}
+		}
+		return cmp.Compare(len(left.Elements), len(right.Elements)), nil
+	}
+
+	return 0, newError(diagnostic.TypeMismatch, "can't put a %s and a %s in order with %s - only numbers, strings and arrays of them can be compared 🤷",
+		left.Type(), right.Type(), operator)
+}
+
+func evalInterpolatedString(
+	node *ast.InterpolatedString,
+	env *object.Environment,
+) object.Object {
+	var out strings.Builder
+
+	for _, part := range node.Parts {
+		value := Eval(part, env)
+		if isError(value) {
+			return value
+		}
+
+		if value == nil {
+			value = NULL
+		}
+
+		out.WriteString(value.Inspect())
+	}
+
+	return &object.String{Value: out.String()}
+}
+
 func evalIfExpression(
 	ie *ast.IfExpression,
 	env *object.Environment,
@@ -499,7 +1041,9 @@ func evalIdentifier(
 		return builtin
 	}
 
-	return newError("%s? never heard of them 🤷‍♀️", node.Value)
+	err := newError(diagnostic.UndefinedVariable, "%s? never heard of them 🤷‍♀️", node.Value)
+	err.Hints = []string{fmt.Sprintf("declare it first with fr %s = ...", node.Value)}
+	return err
 }
 
 func isTruthy(obj object.Object) bool {
@@ -515,8 +1059,14 @@ func isTruthy(obj object.Object) bool {
 	}
 }
 
-func newError(format string, a ...interface{}) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, a...)}
+func newError(code diagnostic.Code, format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...), Code: code}
+}
+
+func newErrorAt(node ast.Node, code diagnostic.Code, format string, a ...interface{}) *object.Error {
+	err := newError(code, format, a...)
+	err.Span = node.Span()
+	return err
 }
 
 func isError(obj object.Object) bool {
@@ -533,6 +1083,15 @@ func evalExpressions(
 	var result []object.Object
 
 	for _, e := range exps {
+		if spread, ok := e.(*ast.SpreadExpression); ok {
+			elements, err := evalSpreadElements(spread, env)
+			if err != nil {
+				return []object.Object{err}
+			}
+			result = append(result, elements...)
+			continue
+		}
+
 		evaluated := Eval(e, env)
 		if isError(evaluated) {
 			return []object.Object{evaluated}
@@ -543,24 +1102,105 @@ func evalExpressions(
 	return result
 }
 
-func applyFunction(fn object.Object, args []object.Object, env *object.Environment) object.Object {
+// evalSpreadElements unpacks ...value into the elements it stands for: the
+// items of an array, the numbers of a range or the characters of a string
+func evalSpreadElements(
+	node *ast.SpreadExpression,
+	env *object.Environment,
+) ([]object.Object, object.Object) {
+	value := Eval(node.Value, env)
+	if isError(value) {
+		return nil, value
+	}
+
+	switch value := value.(type) {
+	case *object.Array:
+		return value.Elements, nil
+	case *object.Range:
+		elements, err := rangeElements(value)
+		if err != nil {
+			return nil, err
+		}
+		return elements, nil
+	case *object.String:
+		elements := []object.Object{}
+		for _, c := range value.Value {
+			elements = append(elements, &object.String{Value: string(c)})
+		}
+		return elements, nil
+	}
+
+	err := newErrorAt(node, diagnostic.TypeMismatch, "you can only spread arrays, ranges and strings in here, not a %s 🧈", value.Type())
+	if value.Type() == object.HASH_OBJ {
+		err.Hints = []string{"hashes spread into other hashes, like {...h, \"key\": 1}"}
+	}
+	return nil, err
+}
+
+// namedArgument is an argument passed by name, like greeting: "sup"
+type namedArgument struct {
+	name  string
+	value object.Object
+}
+
+// evalArguments evaluates call arguments in order, splitting them into the
+// positional ones and the named ones
+func evalArguments(
+	exps []ast.Expression,
+	env *object.Environment,
+) ([]object.Object, []namedArgument, object.Object) {
+	var args []object.Object
+	var named []namedArgument
+
+	for _, e := range exps {
+		if arg, ok := e.(*ast.NamedArgument); ok {
+			value := Eval(arg.Value, env)
+			if isError(value) {
+				return nil, nil, value
+			}
+			named = append(named, namedArgument{name: arg.Name.Value, value: value})
+			continue
+		}
+
+		if spread, ok := e.(*ast.SpreadExpression); ok {
+			elements, err := evalSpreadElements(spread, env)
+			if err != nil {
+				return nil, nil, err
+			}
+			args = append(args, elements...)
+			continue
+		}
+
+		evaluated := Eval(e, env)
+		if isError(evaluated) {
+			return nil, nil, evaluated
+		}
+		args = append(args, evaluated)
+	}
+
+	return args, named, nil
+}
+
+func applyFunction(fn object.Object, args []object.Object, named []namedArgument, env *object.Environment) object.Object {
 	switch fn := fn.(type) {
 
 	case *object.Function:
-		if len(fn.Parameters) != len(args) {
-			return newError("expected %d arguments, but got %d - you sure you know what you're doing? 🤔",
-				len(fn.Parameters), len(args))
+		extendedEnv, err := extendFunctionEnv(fn, args, named)
+		if err != nil {
+			return err
 		}
 
-		extendedEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, extendedEnv)
+		evaluated := evalBlockStatement(fn.Body, extendedEnv)
 		return unwrapReturnValue(evaluated)
 
 	case *object.Builtin:
+		if len(named) > 0 {
+			return newError(diagnostic.InvalidArgument, "%s doesn't take named arguments, just pass them in order 🙏", fn.Name)
+		}
 		return applyBuiltIn(fn, args, env)
 
 	default:
-		return newError("%s can't be cooked! 😭", fn.Type())
+		return newError(diagnostic.NotCallable, "%s can't be cooked! 😭", fn.Type())
 	}
 }
 
@@ -583,24 +1223,138 @@ func applyBuiltIn(fn *object.Builtin, args []object.Object, env *object.Environm
 	return fn.Fn(args...)
 }
 
+// extendFunctionEnv binds the arguments of a call to the parameters of fn.
+// Positional arguments fill the parameters in order, anything left over
+// goes to the ...rest parameter, named arguments fill the parameters with
+// their name, and the parameters still missing get their defaults.
 func extendFunctionEnv(
 	fn *object.Function,
 	args []object.Object,
-) *object.Environment {
+	named []namedArgument,
+) (*object.Environment, object.Object) {
 	env := object.NewEnclosedEnvironment(fn.Env)
 
-	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+	params := fn.Parameters
+	var rest *ast.Parameter
+	if n := len(params); n > 0 && params[n-1].Rest {
+		rest = params[n-1]
+		params = params[:n-1]
+	}
+
+	if len(args) > len(params) && rest == nil {
+		return nil, arityError(fn, len(args)+len(named))
+	}
+
+	// given holds the value of each parameter, or nil when it needs its
+	// default
+	given := make([]object.Object, len(params))
+	copy(given, args)
+
+	for _, arg := range named {
+		found := -1
+		for i, param := range params {
+			if name, ok := param.Name.(*ast.Identifier); ok && name.Value == arg.name {
+				found = i
+			}
+		}
+
+		switch {
+		case found < 0:
+			return nil, newError(diagnostic.InvalidArgument, "there's no parameter called %s - this function takes (%s) 🤔",
+				arg.name, parameterList(fn))
+		case given[found] != nil:
+			return nil, newError(diagnostic.InvalidArgument, "%s got a value twice - pick one 👯", arg.name)
+		}
+
+		given[found] = arg.value
+	}
+
+	missing := []string{}
+	for i, param := range params {
+		if given[i] == nil && param.Default == nil {
+			missing = append(missing, param.Name.String())
+		}
+	}
+
+	if len(missing) > 0 {
+		err := arityError(fn, len(args)+len(named))
+		err.Hints = []string{fmt.Sprintf("nothing was given for %s", strings.Join(missing, ", "))}
+		return nil, err
+	}
+
+	// defaults are worked out on every call, and can use the parameters
+	// before them
+	for i, param := range params {
+		if res := bindElement(param, given[i], env, env.Set); isError(res) {
+			return nil, res
+		}
 	}
 
-	return env
+	if rest != nil {
+		extra := []object.Object{}
+		if len(args) > len(params) {
+			extra = append(extra, args[len(params):]...)
+		}
+		if res := bindPattern(rest.Name, &object.Array{Elements: extra}, env, env.Set); isError(res) {
+			return nil, res
+		}
+	}
+
+	return env, nil
 }
 
+// arityError says how many arguments fn takes, listing its parameters
+func arityError(fn *object.Function, got int) *object.Error {
+	required, optional := 0, 0
+	rest := false
+	for _, param := range fn.Parameters {
+		switch {
+		case param.Rest:
+			rest = true
+		case param.Default != nil:
+			optional++
+		default:
+			required++
+		}
+	}
+
+	var expected string
+	switch {
+	case rest:
+		expected = fmt.Sprintf("at least %d", required)
+	case optional > 0:
+		expected = fmt.Sprintf("%d to %d", required, required+optional)
+	default:
+		expected = fmt.Sprintf("%d", required)
+	}
+
+	noun := "arguments"
+	if !rest && optional == 0 && required == 1 {
+		noun = "argument"
+	}
+
+	return newError(diagnostic.WrongArgumentCount, "expected %s %s (%s), but got %d - you sure you know what you're doing? 🤔",
+		expected, noun, parameterList(fn), got)
+}
+
+func parameterList(fn *object.Function) string {
+	params := make([]string, len(fn.Parameters))
+	for i, param := range fn.Parameters {
+		params[i] = param.String()
+	}
+	return strings.Join(params, ", ")
+}
+
+// unwrapReturnValue gives the value a function call evaluates to. A body
+// that ends in a statement, like fr x = 1, gives back ghosted.
 func unwrapReturnValue(obj object.Object) object.Object {
 	if returnValue, ok := obj.(*object.ReturnValue); ok {
-		return returnValue.Value
+		obj = returnValue.Value
 	}
 
+	if obj == nil {
+		return NULL
+	}
 	return obj
 }
 
@@ -608,13 +1362,17 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
+	case left.Type() == object.RANGE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalRangeIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
-		if left.Type() == object.ARRAY_OBJ {
-			return newError("hey you can only use [] with whole numbers, %s aint it", index.Type())
+		if left.Type() == object.ARRAY_OBJ || left.Type() == object.STRING_OBJ || left.Type() == object.RANGE_OBJ {
+			return newError(diagnostic.InvalidIndex, "hey you can only use [] with whole numbers, %s aint it", index.Type())
 		}
-		return newError("you can't use [] with %s 🤷‍♂️", left.Type())
+		return newError(diagnostic.InvalidIndex, "you can't use [] with %s 🤷‍♂️", left.Type())
 	}
 }
 
@@ -625,29 +1383,109 @@ func evalIndexExpressionAssignmentStatement(item, index, value object.Object) ob
 		idx := index.(*object.Integer).Value
 		max := int64(len(arr.Elements))
 
-		if idx < 1 || idx > max {
-			return newError("this array only goes from 1-%d, but you tried to grab %d - that's way off! 📏", max, idx)
+		i, ok := resolveIndex(idx, max)
+		if !ok {
+			return indexOutOfRangeError("array", idx, max)
 		}
 
-		arr.Elements[idx-1] = value
+		arr.Elements[i] = value
 		return NULL
 
 	case item.Type() == object.ARRAY_OBJ && index.Type() != object.INTEGER_OBJ:
-		return newError("hey you can only use [] with whole numbers, %s aint it", index.Type())
+		return newError(diagnostic.InvalidIndex, "hey you can only use [] with whole numbers, %s aint it", index.Type())
 
 	case item.Type() == object.HASH_OBJ:
 		hashObject := item.(*object.Hash)
 		key, ok := index.(object.Hashable)
 		if !ok {
-			return newError("%s cannot be used as a hash key - try something more primitive 🔑", index.Type())
+			return newError(diagnostic.UnhashableKey, "%s cannot be used as a hash key - try something more primitive 🔑", index.Type())
 		}
 
-		hashObject.Pairs[key.HashKey()] = object.HashPair{Key: index, Value: value}
+		hashObject.Set(key, value)
 		return NULL
 
 	default:
-		return newError("you can't use [] with %s 🤷‍♂️", item.Type())
+		return newError(diagnostic.InvalidIndex, "you can't use [] with %s 🤷‍♂️", item.Type())
+	}
+}
+
+func evalRangeExpression(node *ast.RangeExpression, env *object.Environment) object.Object {
+	start := Eval(node.Start, env)
+	if isError(start) {
+		return start
+	}
+
+	end := Eval(node.End, env)
+	if isError(end) {
+		return end
+	}
+
+	if start.Type() != object.INTEGER_OBJ || end.Type() != object.INTEGER_OBJ {
+		return newError(diagnostic.TypeMismatch, "ranges need whole numbers, not %s and %s - those two don't make a range 📏", start.Type(), end.Type())
+	}
+
+	r := &object.Range{
+		Start: start.(*object.Integer).Value,
+		End:   end.(*object.Integer).Value,
+	}
+	r.Step = object.DefaultRangeStep(r.Start, r.End)
+
+	if node.Step != nil {
+		step := Eval(node.Step, env)
+		if isError(step) {
+			return step
+		}
+
+		if step.Type() != object.INTEGER_OBJ {
+			return newError(diagnostic.TypeMismatch, "a range can only go by whole numbers, not %s 📏", step.Type())
+		}
+
+		r.Step = step.(*object.Integer).Value
+		if r.Step == 0 {
+			return newError(diagnostic.InvalidArgument, "a range can't go by 0 - it would never get anywhere 🐌")
+		}
+	}
+
+	if r.TooLong() {
+		return newError(diagnostic.NumberOutOfRange, "%s has more numbers than a 64 bit integer can count, the max is %d 🤯",
+			r.Inspect(), int64(math.MaxInt64))
+	}
+
+	return r
+}
+
+// maxRangeArray is the most numbers a range can be turned into an array
+// of. Ranges themselves can be far longer since they never store anything.
+const maxRangeArray = 10_000_000
+
+// rangeElements turns r into the numbers it holds, or an error when there
+// are too many of them to keep in memory
+func rangeElements(r *object.Range) ([]object.Object, *object.Error) {
+	n := r.Len()
+	if n > maxRangeArray {
+		err := newError(diagnostic.NumberOutOfRange, "%s has %d numbers, that's too many to put in an array - the max is %d 📏", r.Inspect(), n, maxRangeArray)
+		err.Hints = []string{"stalk can loop over a range of any size without making an array"}
+		return nil, err
+	}
+
+	elements := make([]object.Object, n)
+	for i := range elements {
+		elements[i] = &object.Integer{Value: r.At(int64(i))}
+	}
+	return elements, nil
+}
+
+func evalRangeIndexExpression(rng, index object.Object) object.Object {
+	r := rng.(*object.Range)
+	idx := index.(*object.Integer).Value
+	max := r.Len()
+
+	i, ok := resolveIndex(idx, max)
+	if !ok {
+		return indexOutOfRangeError("range", idx, max)
 	}
+
+	return &object.Integer{Value: r.At(i)}
 }
 
 func evalArrayIndexExpression(array, index object.Object) object.Object {
@@ -655,40 +1493,164 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	idx := index.(*object.Integer).Value
 	max := int64(len(arrayObject.Elements))
 
-	if idx < 1 || idx > max {
-		return newError("this array only goes from 1-%d, but you tried to grab %d - that's way off! 📏", max, idx)
+	i, ok := resolveIndex(idx, max)
+	if !ok {
+		return indexOutOfRangeError("array", idx, max)
 	}
 
-	return arrayObject.Elements[idx-1]
+	return arrayObject.Elements[i]
+}
+
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	chars := []rune(str.(*object.String).Value)
+	idx := index.(*object.Integer).Value
+	max := int64(len(chars))
+
+	i, ok := resolveIndex(idx, max)
+	if !ok {
+		return indexOutOfRangeError("string", idx, max)
+	}
+
+	return &object.String{Value: string(chars[i])}
+}
+
+// resolveIndex turns a 1 based index into a 0 based one. Negative indexes
+// count from the end, so -1 is the last item.
+func resolveIndex(idx, length int64) (int64, bool) {
+	if idx < 0 {
+		idx += length + 1
+	}
+	if idx < 1 || idx > length {
+		return 0, false
+	}
+	return idx - 1, true
+}
+
+func indexOutOfRangeError(kind string, idx, max int64) *object.Error {
+	err := newError(diagnostic.IndexOutOfRange, "this %s only goes from 1-%d, but you tried to grab %d - that's way off! 📏", kind, max, idx)
+	if max > 0 {
+		err.Hints = []string{fmt.Sprintf("you can also count from the end, -1 is the last one and -%d is the first", max)}
+	}
+	return err
+}
+
+// evalSliceExpression gives back a new array, string or range with the
+// items from start to end, both included
+func evalSliceExpression(node *ast.SliceExpression, left object.Object, env *object.Environment) object.Object {
+	var length int64
+	switch left := left.(type) {
+	case *object.Array:
+		length = int64(len(left.Elements))
+	case *object.String:
+		length = int64(utf8.RuneCountInString(left.Value))
+	case *object.Range:
+		length = left.Len()
+	default:
+		return newError(diagnostic.InvalidIndex, "you can't slice a %s ✂️", left.Type())
+	}
+
+	bound := func(exp ast.Expression, fallback int64) (int64, object.Object) {
+		if exp == nil {
+			return fallback, nil
+		}
+		value := Eval(exp, env)
+		if isError(value) {
+			return 0, value
+		}
+		integer, ok := value.(*object.Integer)
+		if !ok {
+			return 0, newError(diagnostic.InvalidIndex, "hey you can only use [] with whole numbers, %s aint it", value.Type())
+		}
+		return integer.Value, nil
+	}
+
+	start, err := bound(node.Start, 1)
+	if err != nil {
+		return err
+	}
+	end, err := bound(node.End, length)
+	if err != nil {
+		return err
+	}
+
+	// negative ends count from the back, like they do for a single index
+	from, to := start, end
+	if from < 0 {
+		from += length + 1
+	}
+	if to < 0 {
+		to += length + 1
+	}
+	if from < 1 || from > length+1 || to < 0 || to > length {
+		return newError(diagnostic.IndexOutOfRange, "this %s only goes from 1-%d, but you tried to slice %d:%d - that's way off! 📏", left.Type(), length, start, end)
+	}
+	if to < from {
+		to = from - 1
+	}
+
+	// from and to are now 0 based and end exclusive
+	from--
+
+	switch left := left.(type) {
+	case *object.Array:
+		elements := make([]object.Object, to-from)
+		copy(elements, left.Elements[from:to])
+		return &object.Array{Elements: elements}
+	case *object.String:
+		return &object.String{Value: string([]rune(left.Value)[from:to])}
+	default:
+		r := left.(*object.Range)
+		first := r.At(from)
+		if to == from {
+			return &object.Range{Start: first, End: first - r.Step, Step: r.Step}
+		}
+		return &object.Range{Start: first, End: r.At(to - 1), Step: r.Step}
+	}
 }
 
 func evalHashLiteral(
 	node *ast.HashLiteral,
 	env *object.Environment,
 ) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
+
+	for _, pair := range node.Pairs {
+		if pair.Spread != nil {
+			spread := Eval(pair.Spread.Value, env)
+			if isError(spread) {
+				return spread
+			}
 
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
+			other, ok := spread.(*object.Hash)
+			if !ok {
+				return newErrorAt(pair.Spread, diagnostic.TypeMismatch, "you can only spread a hash into a hash, not a %s 🧈", spread.Type())
+			}
+
+			for _, p := range other.Pairs() {
+				hash.Set(p.Key.(object.Hashable), p.Value)
+			}
+			continue
+		}
+
+		key := Eval(pair.Key, env)
 		if isError(key) {
 			return key
 		}
 
 		hashKey, ok := key.(object.Hashable)
 		if !ok {
-			return newError("%s cannot be used as a hash key - try something more primitive 🔑", key.Type())
+			return newError(diagnostic.UnhashableKey, "%s cannot be used as a hash key - try something more primitive 🔑", key.Type())
 		}
 
-		value := Eval(valueNode, env)
+		value := Eval(pair.Value, env)
 		if isError(value) {
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(hashKey, value)
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 func evalHashIndexExpression(hash, index object.Object) object.Object {
@@ -696,10 +1658,10 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 
 	key, ok := index.(object.Hashable)
 	if !ok {
-		return newError("%s cannot be used as a hash key - try something more primitive 🔑", index.Type())
+		return newError(diagnostic.UnhashableKey, "%s cannot be used as a hash key - try something more primitive 🔑", index.Type())
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	pair, ok := hashObject.Get(key)
 	if !ok {
 		return NULL
 	}
@@ -707,31 +1669,58 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 	return pair.Value
 }
 
+// evalForStatement loops over arrays, strings, ranges and hashes. With one
+// name it gets the elements (or the keys of a hash), with two it gets the
+// index and the element (or the key and the value of a hash).
 func evalForStatement(node *ast.ForStatement, env *object.Environment) object.Object {
 	items := Eval(node.Items, env)
 	if isError(items) {
 		return items
 	}
 
-	if items.Type() != object.ARRAY_OBJ && items.Type() != object.HASH_OBJ {
-		return newError("%s can't be looped over - try something iterable like an array, string or a hash 🌀", items.Type())
-	}
-
-	var elements []object.Object
-
-	if items.Type() == object.ARRAY_OBJ {
-		elements = items.(*object.Array).Elements
-	} else {
-		hashObj := items.(*object.Hash)
-
-		for _, pair := range hashObj.Pairs {
-			elements = append(elements, pair.Key)
+	// at returns the index or key and the element or value of the i-th
+	// item, so ranges never have to be turned into arrays
+	var n int64
+	var at func(i int64) (object.Object, object.Object)
+
+	switch items := items.(type) {
+	case *object.Array:
+		elements := items.Elements
+		n = int64(len(elements))
+		at = func(i int64) (object.Object, object.Object) {
+			return &object.Integer{Value: i + 1}, elements[i]
+		}
+	case *object.String:
+		chars := []rune(items.Value)
+		n = int64(len(chars))
+		at = func(i int64) (object.Object, object.Object) {
+			return &object.Integer{Value: i + 1}, &object.String{Value: string(chars[i])}
+		}
+	case *object.Range:
+		n = items.Len()
+		at = func(i int64) (object.Object, object.Object) {
+			return &object.Integer{Value: i + 1}, &object.Integer{Value: items.At(i)}
 		}
+	case *object.Hash:
+		pairs := items.Pairs()
+		n = int64(len(pairs))
+		at = func(i int64) (object.Object, object.Object) {
+			if node.Value == nil {
+				return nil, pairs[i].Key
+			}
+			return pairs[i].Key, pairs[i].Value
+		}
+	default:
+		return newError(diagnostic.NotIterable, "%s can't be looped over - try something iterable like an array, range, string or a hash 🌀", items.Type())
 	}
 
 	var result object.Object = NULL
-	for _, element := range elements {
-		extendedEnv := extendForEnv(element, node.Key, env)
+	for i := int64(0); i < n; i++ {
+		key, value := at(i)
+		extendedEnv, err := extendForEnv(node, key, value, env)
+		if err != nil {
+			return err
+		}
 		stmtResult := evalBlockStatement(node.Body, extendedEnv)
 		if stmtResult != nil {
 			switch stmtResult := stmtResult.(type) {
@@ -755,7 +1744,7 @@ func evalForStatement(node *ast.ForStatement, env *object.Environment) object.Ob
 func evalWhileStatement(node *ast.WhileStatement, env *object.Environment) object.Object {
 	var result object.Object = NULL
 	for isTruthy(Eval(node.Condition, env)) {
-		stmtResult := evalBlockStatement(node.Body, env)
+		stmtResult := evalBlockStatement(node.Body, object.NewEnclosedEnvironment(env))
 		if stmtResult != nil {
 			switch stmtResult := stmtResult.(type) {
 			case *object.Error:
@@ -775,10 +1764,21 @@ func evalWhileStatement(node *ast.WhileStatement, env *object.Environment) objec
 	return result
 }
 
-func extendForEnv(item object.Object, key *ast.Identifier, e *object.Environment) *object.Environment {
+func extendForEnv(node *ast.ForStatement, key, value object.Object, e *object.Environment) (*object.Environment, object.Object) {
 	env := object.NewEnclosedEnvironment(e)
 
-	env.Set(key.Value, item)
+	if node.Value == nil {
+		if res := bindPattern(node.Key, value, env, env.Set); isError(res) {
+			return nil, res
+		}
+	} else {
+		if res := bindPattern(node.Key, key, env, env.Set); isError(res) {
+			return nil, res
+		}
+		if res := bindPattern(node.Value, value, env, env.Set); isError(res) {
+			return nil, res
+		}
+	}
 
-	return env
+	return env, nil
 }
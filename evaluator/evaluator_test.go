@@ -1,9 +1,12 @@
 package evaluator
 
 import (
+	"fmt"
+	"nocap/diagnostic"
 	"nocap/lexer"
 	"nocap/object"
 	"nocap/parser"
+	"strings"
 	"testing"
 )
 
@@ -71,6 +74,299 @@ func TestEvalIntegerAndFloatExpression(t *testing.T) {
 	}
 }
 
+func TestPowerAndFloorDivision(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"(-2) ** 3", -8},
+		{"5 ** 0", 1},
+		{"2 ** -1", 0.5},
+		{"4 ** 0.5", 2.0},
+		{"1.5 ** 2", 2.25},
+		{"7 ~/ 2", 3},
+		{"-7 ~/ 2", -4},
+		{"7 ~/ -2", -4},
+		{"-7 ~/ -2", 3},
+		{"6 ~/ 3", 2},
+		{"7.5 ~/ 2", 3.0},
+		{"101 ~/ 20 + 1", 6},
+		{"7 ~/ 0", "my math teacher said no dividing by zero! 😤"},
+		{"0 ** -1", "my math teacher said no dividing by zero! 😤"},
+		{"0.0 ** -1", "my math teacher said no dividing by zero! 😤"},
+		{"0 ** -0.5", "my math teacher said no dividing by zero! 😤"},
+		{"0 ** 0", 1},
+		{"0.0 ** 2", 0.0},
+		{"2 ** 63", "2 ** 63 is too big for a 64 bit integer 🤯"},
+		{"9223372036854775807 + 1", "9223372036854775807 + 1 is too big for a 64 bit integer 🤯"},
+		{"-9223372036854775807 - 2", "-9223372036854775807 - 2 is too big for a 64 bit integer 🤯"},
+		{"3037000500 * 3037000500", "3037000500 * 3037000500 is too big for a 64 bit integer 🤯"},
+		{"2 ** 62 * 2", "4611686018427387904 * 2 is too big for a 64 bit integer 🤯"},
+		{"-(-9223372036854775807 - 1)", "-(-9223372036854775808) is too big for a 64 bit integer 🤯"},
+		{"(-9223372036854775807 - 1) ~/ -1", "-9223372036854775808 ~/ -1 is too big for a 64 bit integer 🤯"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestBitwiseOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"12 & 10", 8},
+		{"12 | 10", 14},
+		{"12 ^ 10", 6},
+		{"~0", -1},
+		{"~5", -6},
+		{"~~5", 5},
+		{"1 << 4", 16},
+		{"256 >> 4", 16},
+		{"-16 >> 2", -4},
+		{"1 << 62", 4611686018427387904},
+		{"-1 << 63", -9223372036854775807 - 1},
+		{"0 << 100", 0},
+		{"-1 >> 100", -1},
+		{"1 >> 64", 0},
+		{"1 << 63", "1 << 63 is too big for a 64 bit integer 🤯"},
+		{"1 << 64", "1 << 64 is too big for a 64 bit integer 🤯"},
+		{"3 << 62", "3 << 62 is too big for a 64 bit integer 🤯"},
+		{"-3 << 62", "-3 << 62 is too big for a 64 bit integer 🤯"},
+		{"0xFF & ~0x0F", 0xF0},
+		{"1 | 2 ^ 3 & 4", 3},
+		{"1 << 2 + 1", 8},
+		{"fr perms = 0b101; perms & 0b100 aint 0", true},
+		{"1 << -1", "can't shift by -1, the shift amount can't be negative 🙅"},
+		{"1.5 & 1", "& only works on whole numbers, but you gave it a float and a integer 🔢"},
+		{`"a" | "b"`, "| only works on whole numbers, but you gave it a string and a string 🔢"},
+		{"noCap ^ cap", "^ only works on whole numbers, but you gave it a boolean and a boolean 🔢"},
+		{"~1.5", "~ only works on whole numbers, but you gave it a float 🔢"},
+		{`~"yo"`, "~ only works on whole numbers, but you gave it a string 🔢"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestNullSafeOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"ghosted ?? 5", 5},
+		{"3 ?? 5", 3},
+		{"cap ?? 5", false},
+		{"0 ?? 5", 0},
+		{`fr h = {"a": 1}; h["b"] ?? 2`, 2},
+		{`fr h = {"a": 1}; h["a"] ?? 2`, 1},
+		{"ghosted ?? ghosted ?? 7", 7},
+		{"fr calls = 0; cook bump() { calls = calls + 1; yeet 1 }; 1 ?? bump(); calls", 0},
+		{"fr calls = 0; cook bump() { calls = calls + 1; yeet 1 }; ghosted ?? bump(); calls", 1},
+		{`fr config = {"db": {"port": 5432}}; config?["db"]?["port"]`, 5432},
+		{`fr config = {"db": {"port": 5432}}; config?["cache"]?["port"] ?? 6379`, 6379},
+		{"fr nothing = ghosted; nothing?[1]", nil},
+		{"[10, 20]?[2]", 20},
+		{"fr i = 0; cook next() { i = i + 1; yeet i }; ghosted?[next()]; i", 0},
+		{"ghosted ?? missing", "missing? never heard of them 🤷‍♀️"},
+		{"ghosted?[1][2]", nil},
+		{"fr x = ghosted; x?[1][2]", nil},
+		{`{"a": ghosted}?["a"]["b"]`, nil},
+		{`fr config = {"db": {"port": 5432}}; config?["cache"]["port"] ?? 6379`, 6379},
+		{`fr config = {"db": {"port": 5432}}; config?["db"]["port"]`, 5432},
+		{`{"a": {}}?["a"]["b"]["c"]`, "you can't use [] with ghosted 🤷‍♂️"},
+		{`fr config = {"db": ghosted}; config?["db"]?["hosts"][1:2]`, nil},
+		{`fr h = {"f": cook(x) { x * 2 }}; h?["f"](4)`, 8},
+		{"fr calls = 0; cook bump() { calls = calls + 1; yeet 1 }; ghosted?[1][bump()](bump()); calls", 0},
+		{"fr x = ghosted; x?[1][2] ?? 3", 3},
+		{"ghosted[1]?[2]", "you can't use [] with ghosted 🤷‍♂️"},
+		{"cook f() { fr x = 1 }; f() ?? 3", 3},
+		{"cook f() { fr x = 1 }; (f() ?? 3) + 1", 4},
+		{"cook f() { fr x = 1 }; f()?[1]", nil},
+		{"cook f() { fr x = 1 }; f()?[1][2](3)", nil},
+		{"cook f() { fr x = 1 }; f()?[1:2]", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestPipeOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"cook double(x) { x * 2 }; 5 |> double", 10},
+		{"cook sub(a, b) { a - b }; 10 |> sub(3)", 7},
+		{"cook double(x) { x * 2 }; cook sub(a, b) { a - b }; 1 + 2 |> double |> sub(1)", 5},
+		{"[1, 2, 3] |> count", 3},
+		{"[1, 2] |> slide(3) |> count", 3},
+		{"5 |> cook(n) { n * n }", 25},
+		{"cook adder(a) { cook(b) { a + b } }; 1 |> adder(10)()", 11},
+		{"cook adder(a) { cook(b) { a + b } }; 1 |> (10 |> adder)", 11},
+		{"5 |> missing", "missing? never heard of them 🤷‍♀️"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestRanges(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1..5", "1..5"},
+		{"5..1", "5..1"},
+		{"10..1 by -2", "10..1 by -2"},
+		{"1..10 by 1", "1..10"},
+		{"count(1..10)", 10},
+		{"count(10..1)", 10},
+		{"count(1..10 by 3)", 4},
+		{"count(10..1 by -2)", 5},
+		{"count(1..10 by -1)", 0},
+		{"count(3..3)", 1},
+		{"count(1..1000000000000)", 1000000000000},
+		{"(1..10)[1]", 1},
+		{"(1..10)[10]", 10},
+		{"(10..1 by -3)[4]", 1},
+		{"fr r = 0..100 by 5; r[3]", 10},
+		{"fr total = 0; stalk (i in 1..4) { total += i } total", 10},
+		{"fr total = 0; stalk (i in 10..1 by -3) { total = total * 100 + i } total", 10070401},
+		{"fr total = 0; stalk (i in 1..1000000000000) { vibe (i > 3) { bounce; } total += i } total", 6},
+		{"fr seen = 0; stalk (i in 1..5 by -1) { seen += 1 } seen", 0},
+		{"cook f() { stalk (i in 1..10) { vibe (i is 7) { yeet i } } }; f()", 7},
+		{"spread(1..3)", []int64{1, 2, 3}},
+		{"spread(3..1)", []int64{3, 2, 1}},
+		{"spread(1..9000000000000000000)", errorMessage("1..9000000000000000000 has 9000000000000000000 numbers, that's too many to put in an array - the max is 10000000 📏")},
+		{"(1..10)[11]", errorMessage("this range only goes from 1-10, but you tried to grab 11 - that's way off! 📏")},
+		{"(1..10)[0]", errorMessage("this range only goes from 1-10, but you tried to grab 0 - that's way off! 📏")},
+		{"1..10 by 0", errorMessage("a range can't go by 0 - it would never get anywhere 🐌")},
+		{"count(0..9223372036854775806)", 9223372036854775807},
+		{"(-9223372036854775807 - 1..9223372036854775807 by 3)[-1]", 9223372036854775807},
+		{"count(-9223372036854775807..9223372036854775807)",
+			errorMessage("-9223372036854775807..9223372036854775807 has more numbers than a 64 bit integer can count, the max is 9223372036854775807 🤯")},
+		{"0..9223372036854775807", errorMessage("0..9223372036854775807 has more numbers than a 64 bit integer can count, the max is 9223372036854775807 🤯")},
+		{`1.."z"`, errorMessage("ranges need whole numbers, not integer and string - those two don't make a range 📏")},
+		{"1.5..3", errorMessage("ranges need whole numbers, not float and integer - those two don't make a range 📏")},
+		{"1..3 by 0.5", errorMessage("a range can only go by whole numbers, not float 📏")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			rng, ok := evaluated.(*object.Range)
+			if !ok {
+				t.Errorf("object is not Range for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if rng.Inspect() != expected {
+				t.Errorf("range has wrong value. expected=%q, got=%q", expected, rng.Inspect())
+			}
+		case []int64:
+			array, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Errorf("object is not Array for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if len(array.Elements) != len(expected) {
+				t.Errorf("wrong num of elements. want=%d, got=%d", len(expected), len(array.Elements))
+				continue
+			}
+			for i, expectedElem := range expected {
+				testIntegerObject(t, array.Elements[i], expectedElem)
+			}
+		case errorMessage:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != string(expected) {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+// errorMessage marks an expected error in tests whose other cases are strings
+type errorMessage string
+
 func TestEvalBooleanExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -119,6 +415,93 @@ func TestEvalBooleanExpression(t *testing.T) {
 	}
 }
 
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"[1, 2] is [1, 2]", true},
+		{"[1, 2] aint [1, 2]", false},
+		{"[1, 2] is [2, 1]", false},
+		{"[1, 2] is [1, 2, 3]", false},
+		{"[1, 2.0] is [1.0, 2]", true},
+		{`[1, [2, "three"]] is [1, [2, "three"]]`, true},
+		{`[1, [2, "three"]] is [1, [2, "four"]]`, false},
+		{`{"a": 1, "b": [2]} is {"b": [2], "a": 1}`, true},
+		{`{"a": 1} is {"a": 2}`, false},
+		{`{"a": 1} is {"a": 1, "b": 2}`, false},
+		{`{1: "x"} is {1.0: "x"}`, true},
+		{"[] is []", true},
+		{"{} is {}", true},
+		{"[] is {}", false},
+		{"1..3 is 1..3", true},
+		{"1..3 is 1..4", false},
+		{"ghosted is ghosted", true},
+		{"ghosted aint ghosted", false},
+		{"ghosted is 0", false},
+		{"[ghosted] is [ghosted]", true},
+		{`1 is "1"`, false},
+		{`1 aint "1"`, true},
+		{`[1] is ["1"]`, false},
+		{"fr f = cook() { 1 }; [f] is [f]", true},
+		{"[cook() { 1 }] is [cook() { 1 }]", false},
+		{"fr a = [1, 2]; a[2] = a; a is a", true},
+		{"fr a = [1, 2]; a[2] = a; fr b = [1, 2]; b[2] = b; a is b", true},
+		{"fr a = [1, 2]; a[2] = a; fr b = [2, 2]; b[2] = b; a is b", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.input)
+		}
+	}
+}
+
+func TestOrderingComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`"apple" < "banana"`, true},
+		{`"apple" > "banana"`, false},
+		{`"apple" <= "apple"`, true},
+		{`"apple" >= "apples"`, false},
+		{`"Zebra" < "apple"`, true},
+		{"[1, 2] < [1, 3]", true},
+		{"[1, 2] < [1, 2]", false},
+		{"[1, 2] <= [1, 2]", true},
+		{"[1, 2] < [1, 2, 0]", true},
+		{"[2] > [1, 9, 9]", true},
+		{"[] < [1]", true},
+		{"[1, 1.5] < [1, 2]", true},
+		{`[["b"]] > [["a", "z"]]`, true},
+		{"fr a = [1, 2]; a[2] = a; a <= a", true},
+		{`[1, "a"] < [1, 2]`, "can't put a string and a integer in order with < - only numbers, strings and arrays of them can be compared 🤷"},
+		{`[noCap] < [cap]`, "can't put a boolean and a boolean in order with < - only numbers, strings and arrays of them can be compared 🤷"},
+		{"[1] + [2]", "idk how to + a array with a array 😬"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			if !testBooleanObject(t, evaluated, expected) {
+				t.Errorf("wrong result for %q", tt.input)
+			}
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
 func TestBangOperator(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -461,7 +844,7 @@ vibe (10 > 1) {
 		},
 		{
 			`cook(x) { x }(1, 2)`,
-			"expected 1 arguments, but got 2 - you sure you know what you're doing? 🤔",
+			"expected 1 argument (x), but got 2 - you sure you know what you're doing? 🤔",
 		},
 		{
 			`[1, 2, 3][4]`,
@@ -486,6 +869,97 @@ vibe (10 > 1) {
 	}
 }
 
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"fr x = 1;\nfr y = x + noCap;",
+			"2:8: what the hell is + supposed to do between a integer and a boolean 🐘🐧",
+		},
+		{
+			"fr f = cook() {\n  yeet  nope;\n};\nf();",
+			"2:9: nope? never heard of them 🤷‍♀️",
+		},
+		{
+			"fr x = 1;\n\n  bounce;",
+			"3:3: hey! you can't just bounce outside of a loop 🫠",
+		},
+		{
+			"count(1, 2)",
+			"1:1: count needs 1 argument but you gave it 2 🥲",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Inspect() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errObj.Inspect())
+		}
+	}
+}
+
+func TestErrorDiagnostics(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedCode  diagnostic.Code
+		expectedSpan  string
+		expectedHints []string
+	}{
+		{"1 / 0", diagnostic.DivisionByZero, "1:1-1:6", nil},
+		{"fr x = 1;\nx + cap", diagnostic.TypeMismatch, "2:1-2:8", nil},
+		{"nope", diagnostic.UndefinedVariable, "1:1-1:5", []string{"declare it first with fr nope = ..."}},
+		{"nope = 1", diagnostic.UndefinedVariable, "1:1-1:9", []string{"use fr nope = ... the first time you give it a value"}},
+		{"[1, 2][3]", diagnostic.IndexOutOfRange, "1:1-1:10", []string{"you can also count from the end, -1 is the last one and -2 is the first"}},
+		{"count(1, 2)", diagnostic.WrongArgumentCount, "1:1-1:12", nil},
+		{"5()", diagnostic.NotCallable, "1:1-1:4", nil},
+		{"stalk (x in 5) { x }", diagnostic.NotIterable, "1:1-1:21", nil},
+		{"{[1]: 2}", diagnostic.UnhashableKey, "1:1-1:9", nil},
+		{"9223372036854775807 + 1", diagnostic.IntegerOverflow, "1:1-1:24", nil},
+		{"bounce;", diagnostic.LoopControlOutsideLoop, "1:1-1:7", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		d := errObj.Diagnostic()
+		if d.Severity != diagnostic.Error {
+			t.Errorf("%q: wrong severity. got=%s", tt.input, d.Severity)
+		}
+
+		if d.Code != tt.expectedCode {
+			t.Errorf("%q: wrong code. expected=%s, got=%s", tt.input, tt.expectedCode, d.Code)
+		}
+
+		span := fmt.Sprintf("%d:%d-%d:%d", d.Span.Start.Line, d.Span.Start.Column, d.Span.End.Line, d.Span.End.Column)
+		if span != tt.expectedSpan {
+			t.Errorf("%q: wrong span. expected=%s, got=%s", tt.input, tt.expectedSpan, span)
+		}
+
+		if strings.Join(d.Hints, "|") != strings.Join(tt.expectedHints, "|") {
+			t.Errorf("%q: wrong hints. expected=%q, got=%q", tt.input, tt.expectedHints, d.Hints)
+		}
+
+		if d.Message != errObj.Message || d.String() != errObj.Inspect() {
+			t.Errorf("%q: diagnostic doesn't match the error. got=%q", tt.input, d.String())
+		}
+	}
+}
+
 func TestLetStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -564,6 +1038,181 @@ ourFunction(20) + first + second;`
 	testIntegerObject(t, testEval(input), 70)
 }
 
+func TestDefaultRestAndNamedArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`cook greet(name, greeting = "yo") { greeting + " " + name }; greet("bestie")`, "yo bestie"},
+		{`cook greet(name, greeting = "yo") { greeting + " " + name }; greet("bestie", "sup")`, "sup bestie"},
+		{`cook greet(name, greeting = "yo") { greeting + " " + name }; greet(greeting: "gm", name: "bestie")`, "gm bestie"},
+		{`cook greet(name, greeting = "yo") { greeting + " " + name }; greet("bestie", greeting: "gn")`, "gn bestie"},
+		{`cook f(a, b = a * 2) { [a, b] }; f(3)`, "[3, 6]"},
+		{`cook f(a = 1, b = 2) { [a, b] }; f(b: 5)`, "[1, 5]"},
+		{`fr calls = 0; cook next() { calls += 1; calls }; cook f(x = next()) { x }; f(); f(); f(10); calls`, "2"},
+		{`cook f(a, ...rest) { [a, rest] }; f(1, 2, 3)`, "[1, [2, 3]]"},
+		{`cook f(a, ...rest) { [a, rest] }; f(1)`, "[1, []]"},
+		{`cook f(...all) { count(all) }; f()`, "0"},
+		{`cook f(a, b = 2, ...rest) { [a, b, rest] }; f(1, 9, 8, 7)`, "[1, 9, [8, 7]]"},
+		{`fr sum = cook(...nums) { fr total = 0; stalk (n in nums) { total += n } total }; sum(1, 2, 3, 4)`, "10"},
+		{`cook f(a, b) { a - b }; 10 |> f(b: 3)`, "7"},
+		{`cook f(a, b = 2) { a + b }; f()`,
+			"expected 1 to 2 arguments (a, b = 2), but got 0 - you sure you know what you're doing? 🤔"},
+		{`cook f(a, b) { a + b }; f(1, 2, 3)`,
+			"expected 2 arguments (a, b), but got 3 - you sure you know what you're doing? 🤔"},
+		{`cook f(a, ...rest) { a }; f()`,
+			"expected at least 1 arguments (a, ...rest), but got 0 - you sure you know what you're doing? 🤔"},
+		{`cook f(a, b) { a + b }; f(1, c: 2)`, "there's no parameter called c - this function takes (a, b) 🤔"},
+		{`cook f(a, ...rest) { a }; f(1, rest: 2)`, "there's no parameter called rest - this function takes (a, ...rest) 🤔"},
+		{`cook f(a, b) { a + b }; f(1, a: 2)`, "a got a value twice - pick one 👯"},
+		{`count(arr: [1])`, "count doesn't take named arguments, just pass them in order 🙏"},
+		{`cook f(a = nope) { a }; f()`, "nope? never heard of them 🤷‍♀️"},
+		{`cook f() { fr x = 1 }; cook g(a) { a }; g(f())`, "ghosted"},
+		{`cook f() { fr x = 1 }; cook g(a = 5) { a }; g(f())`, "ghosted"},
+		{`cook f() { fr x = 1 }; cook g(a = 5) { a }; g(a: f())`, "ghosted"},
+		{`cook f() { fr x = 1 }; cook g(...rest) { rest }; g(f())`, "[ghosted]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		got := evaluated.Inspect()
+		if errObj, ok := evaluated.(*object.Error); ok {
+			got = errObj.Message
+		}
+		if got != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	evaluated := testEval(`cook f(a, b, c = 3) { a }; f(b: 2)`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+	if strings.Join(errObj.Hints, "|") != "nothing was given for a" {
+		t.Errorf("wrong hints. got=%q", errObj.Hints)
+	}
+}
+
+func TestSpreadExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fr a = [2, 3]; [1, ...a, 4]`, "[1, 2, 3, 4]"},
+		{`[...[], ...[1]]`, "[1]"},
+		{`[...1..3, ...5..1 by -2]`, "[1, 2, 3, 5, 3, 1]"},
+		{`[..."sup"]`, "[s, u, p]"},
+		{`fr a = [1]; fr b = [...a]; b[1] = 2; a`, "[1]"},
+		{`cook add(a, b, c) { a + b + c }; add(...[1, 2, 3])`, "6"},
+		{`cook add(a, b, c) { a + b + c }; add(1, ...[2], 3)`, "6"},
+		{`cook f(a, ...rest) { [a, rest] }; f(...1..4)`, "[1, [2, 3, 4]]"},
+		{`cook f(a, b = 2) { [a, b] }; f(...[1], b: 5)`, "[1, 5]"},
+		{`count(...[[1, 2]])`, "2"},
+		{`[1, 2] |> slide(...[3])`, "[1, 2, 3]"},
+		{`fr h = {"a": 1, "b": 2}; {...h, "c": 3}`, "{a: 1, b: 2, c: 3}"},
+		{`fr h = {"a": 1, "b": 2}; {"b": 0, ...h}`, "{b: 2, a: 1}"},
+		{`fr h = {"a": 1, "b": 2}; {...h, "a": 9}`, "{a: 9, b: 2}"},
+		{`{...{1: "x"}, ...{1.0: "y"}}`, "{1: y}"},
+		{`[...5]`, "you can only spread arrays, ranges and strings in here, not a integer 🧈"},
+		{`cook f(a) { a }; f(...{"a": 1})`, "you can only spread arrays, ranges and strings in here, not a hash 🧈"},
+		{`{...[1, 2]}`, "you can only spread a hash into a hash, not a array 🧈"},
+		{`[...nope]`, "nope? never heard of them 🤷‍♀️"},
+		{`cook f() { fr x = 1 }; [...f()]`, "you can only spread arrays, ranges and strings in here, not a ghosted 🧈"},
+		{`[...(1..9000000000000000000)]`, "1..9000000000000000000 has 9000000000000000000 numbers, that's too many to put in an array - the max is 10000000 📏"},
+		{`cook f(...all) { all }; f(...(1..9000000000000000000))`, "1..9000000000000000000 has 9000000000000000000 numbers, that's too many to put in an array - the max is 10000000 📏"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		got := evaluated.Inspect()
+		if errObj, ok := evaluated.(*object.Error); ok {
+			got = errObj.Message
+		}
+		if got != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	evaluated := testEval(`[...{"a": 1}]`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+	if errObj.Span.Start.Column != 2 {
+		t.Errorf("wrong column. got=%d", errObj.Span.Start.Column)
+	}
+	if len(errObj.Hints) != 1 {
+		t.Errorf("expected a hint for spreading a hash. got=%q", errObj.Hints)
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fr [a, b] = [1, 2]; a + b`, "3"},
+		{`fr [first, ...rest] = [1, 2, 3]; [first, rest]`, "[1, [2, 3]]"},
+		{`fr [a, b, ...rest] = [1]; [a, b, rest]`, "[1, ghosted, []]"},
+		{`fr [a, b = a * 10] = [4]; b`, "40"},
+		{`fr [a = 1] = [ghosted]; a`, "ghosted"},
+		{`fr [a] = [1, 2, 3]; a`, "1"},
+		{`fr [x, y] = 1..1000000000; [x, y]`, "[1, 2]"},
+		{`fr [x, ...rest] = 1..10 by 3; [x, rest]`, "[1, [4, 7, 10]]"},
+		{`fr [x, y, ...rest] = 1..2; rest`, "[]"},
+		{`fr [x, ...rest] = 1..9000000000000000000`,
+			"2..9000000000000000000 has 8999999999999999999 numbers, that's too many to put in an array - the max is 10000000 📏"},
+		{`fr [h, ...tail] = "sup"; [h, tail]`, "[s, [u, p]]"},
+		{`fr [[a, b], [c]] = [[1, 2], [3]]; a + b + c`, "6"},
+		{`fr {name, age} = {"name": "bestie", "age": 21}; name + " " + age`, "bestie 21"},
+		{`fr {name: n, mood = "chill"} = {"name": "bestie"}; [n, mood]`, "[bestie, chill]"},
+		{`fr {missing} = {}; missing`, "ghosted"},
+		{`fr {a, ...others} = {"a": 1, "b": 2, 3: 4}; others`, "{b: 2, 3: 4}"},
+		{`fr {address: {city}, tags: [top]} = {"address": {"city": "nyc"}, "tags": ["fyp"]}; city + " " + top`, "nyc fyp"},
+		{`fr x = 1; vibe (noCap) { fr [x, y] = [2, 3] } x`, "1"},
+		{`deadass [a, b] = [1, 2]; a = 3`, "a is deadass - you can't change it once it's set 🔒"},
+		{`fr total = 0; stalk ([k, v] in [["a", 1], ["b", 2]]) { total += v } total`, "3"},
+		{`fr names = ""; stalk (i, {name} in [{"name": "a"}, {"name": "b"}]) { names += name } names`, "ab"},
+		{`cook dist([x1, y1], [x2, y2]) { (x2 - x1) + (y2 - y1) }; dist([1, 1], [4, 5])`, "7"},
+		{`cook greet({name, greeting = "yo"}) { greeting + " " + name }; greet({"name": "bestie"})`, "yo bestie"},
+		{`cook f([a, b] = [1, 2]) { a + b }; f()`, "3"},
+		{`cook f([a, b]) { a }; f()`,
+			"expected 1 argument ([a, b]), but got 0 - you sure you know what you're doing? 🤔"},
+		{`fr [a, b] = 5`, "can't unpack a integer into [a, b] - only arrays, ranges and strings fit in a [...] pattern 📦"},
+		{`fr {a} = [1]`, "can't unpack a array into {a} - only hashes fit in a {...} pattern 📦"},
+		{`fr [[a]] = []`, "can't unpack a ghosted into [a] - only arrays, ranges and strings fit in a [...] pattern 📦"},
+		{`cook f() { fr x = 1 }; fr [a = 2] = [f()]; a`, "ghosted"},
+		{`cook f() { fr x = 1 }; fr {a = 2} = {"a": f()}; a`, "ghosted"},
+		{`cook f() { fr x = 1 }; fr [a] = f()`, "can't unpack a ghosted into [a] - only arrays, ranges and strings fit in a [...] pattern 📦"},
+		{`cook f() { fr x = 1 }; fr {a} = f()`, "can't unpack a ghosted into {a} - only hashes fit in a {...} pattern 📦"},
+		{`cook f() { fr x = 1 }; stalk ([a] in [f()]) { a }`, "can't unpack a ghosted into [a] - only arrays, ranges and strings fit in a [...] pattern 📦"},
+		{`fr {user: {name}} = {}`, "can't unpack a ghosted into {name} - only hashes fit in a {...} pattern 📦"},
+		{`stalk ([a] in [1]) { a }`, "can't unpack a integer into [a] - only arrays, ranges and strings fit in a [...] pattern 📦"},
+		{`cook f([a]) { a }; f([1], a: 2)`, "there's no parameter called a - this function takes ([a]) 🤔"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		got := evaluated.Inspect()
+		if errObj, ok := evaluated.(*object.Error); ok {
+			got = errObj.Message
+		}
+		if got != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	evaluated := testEval(`fr [a, [b]] = [1, 2]`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+	if errObj.Span.String() != "1:8" {
+		t.Errorf("wrong span. got=%s", errObj.Span)
+	}
+}
+
 func TestClosures(t *testing.T) {
 	input := `
 fr newAdder = cook(x) {
@@ -590,6 +1239,52 @@ func TestStringLiteral(t *testing.T) {
 	}
 }
 
+func TestStringEscapesAndRawStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"report:\n\t\"done\""`, "report:\n\t\"done\""},
+		{`"\u{1F525}" + " lit"`, "🔥 lit"},
+		{"`C:\\path\\no\\escapes`", `C:\path\no\escapes`},
+		{"fr report = `line one\n\"line two\"`; report", "line one\n\"line two\""},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testStringObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fr name = "bestie"; "yo ${name}"`, "yo bestie"},
+		{`fr items = [1, 2, 3]; "you got ${count(items)} items"`, "you got 3 items"},
+		{`"math: ${1 + 2 * 3}, vibes: ${noCap}, ghost: ${ghosted}"`, "math: 7, vibes: noCap, ghost: ghosted"},
+		{`fr x = 2; "outer ${"inner ${x * 2}"}"`, "outer inner 4"},
+		{`fr h = {"a": [1, 2]}; "${h["a"]}"`, "[1, 2]"},
+		{`"literal \${name}"`, "literal ${name}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testStringObject(t, evaluated, tt.expected)
+	}
+
+	evaluated := testEval(`"yo ${nope}"`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Inspect() != "1:7: nope? never heard of them 🤷‍♀️" {
+		t.Errorf("wrong error. got=%q", errObj.Inspect())
+	}
+}
+
 func TestStringConcatenation(t *testing.T) {
 	input := `"Hello" + " " + "World!"`
 
@@ -662,13 +1357,16 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`count("")`, 0},
 		{`count("four")`, 4},
 		{`count("hello world")`, 11},
-		{`count(1)`, "count can only be used with arrays, strings, or hashes, not integer 🙄"},
+		{`count(1)`, "count can only be used with arrays, strings, hashes, or ranges, not integer 🙄"},
 		{`count("one", "two")`, "count needs 1 argument but you gave it 2 🥲"},
 		{`count([1, 2, 3])`, 3},
 		{`count([])`, 0},
 		{`caughtIn4K("hello", "world!")`, nil},
 		{`slide([], 1)`, []int{1}},
 		{`slide(1, 1)`, "slide needs an array to work with, not integer - can't slide on that! 🛝"},
+		{`count("café")`, 4},
+		{`count("🔥🔥")`, 2},
+		{`count(spread("héllo"))`, 5},
 	}
 
 	for _, tt := range tests {
@@ -779,6 +1477,36 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"hello"[1]`, "h"},
+		{`"hello"[5]`, "o"},
+		{`"café"[4]`, "é"},
+		{`fr s = "yo 🔥 fam"; s[4]`, "🔥"},
+		{`spread("naïve")[3]`, "ï"},
+		{`fr 🔥 = "lit"; 🔥[2]`, "i"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testStringObject(t, evaluated, tt.expected)
+	}
+
+	evaluated := testEval(`"café"[5]`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expected := "this string only goes from 1-4, but you tried to grab 5 - that's way off! 📏"
+	if errObj.Message != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+	}
+}
+
 func TestHashLiterals(t *testing.T) {
 	input := `fr two = "two";
 	{
@@ -796,26 +1524,76 @@ func TestHashLiterals(t *testing.T) {
 		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
 	}
 
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
 	}
 
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	if result.Len() != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", result.Len())
 	}
 
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+	for _, tt := range expected {
+		pair, ok := result.Get(tt.key)
 		if !ok {
 			t.Errorf("no pair for given key in Pairs")
 		}
 
-		testIntegerObject(t, pair.Value, expectedValue)
+		testIntegerObject(t, pair.Value, tt.value)
+	}
+}
+
+func TestNumericHashKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{1: "a"}[1.0]`, "a"},
+		{`{1.0: "a"}[1]`, "a"},
+		{`{1: "a", 1.0: "b"}`, "{1: b}"},
+		{`fr h = {2.5: "x"}; h[2.5]`, "x"},
+		{`count({0.0000001: 1, 0.0000002: 2})`, "2"},
+		{`{0.0000001: 1, 0.0000002: 2}[0.0000002]`, "2"},
+		{`{1: "int", "1": "string", noCap: "bool"}[1.0]`, "int"},
+		{`count({1: "int", "1": "string", noCap: "bool"})`, "3"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"z": 1, "a": 2, "m": 3}`, "{z: 1, a: 2, m: 3}"},
+		{`{3: "c", 1: "a", 2: "b"}`, "{3: c, 1: a, 2: b}"},
+		{`{"a": 1, "b": 2, "a": 3}`, "{a: 3, b: 2}"},
+		{`fr h = {"b": 1}; h["a"] = 2; h["c"] = 3; h["b"] = 4; h`, "{b: 4, a: 2, c: 3}"},
+		{`fr keys = ""; stalk (k in {"x": 1, "y": 2, "w": 3, "v": 4}) { keys += k } keys`, "xywv"},
+		{`fr log = ""; cook note(s) { log += s; yeet s }; {note("b"): note("1"), note("a"): note("2")}; log`, "b1a2"},
+	}
+
+	for _, tt := range tests {
+		// run each case a few times, map iteration order would change between runs
+		for i := 0; i < 20; i++ {
+			evaluated := testEval(tt.input)
+			if evaluated.Inspect() != tt.expected {
+				t.Fatalf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+			}
+		}
 	}
 }
 
@@ -898,6 +1676,58 @@ func TestAssigmentError(t *testing.T) {
 	}
 }
 
+func TestCompoundAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"fr i = 0; onRepeat (i < 5) { i += 1; } i", 5},
+		{"fr x = 10; x -= 3; x", 7},
+		{"fr x = 4; x *= 2.5; x", 10.0},
+		{"fr x = 7; x /= 2; x", 3.5},
+		{"fr x = 7; x %= 4; x", 3},
+		{`fr s = "yo"; s += " fam"; s`, "yo fam"},
+		{"fr arr = [1, 2, 3]; arr[2] += 10; arr[2]", 12},
+		{`fr h = {"hits": 1}; h["hits"] *= 5; h["hits"]`, 5},
+		{`fr h = {"a": [1, 2]}; h["a"][1] -= 1; h["a"][1]`, 0},
+		{"fr x = 1; fr bump = cook() { x += 1; }; bump(); bump(); x", 3},
+		// the target is evaluated only once
+		{"fr calls = 0; fr arr = [0, 0]; fr pick = cook() { calls += 1; yeet calls; }; arr[pick()] += 5; [calls, arr[1], arr[2]]", []int{1, 5, 0}},
+		{"fr x = 1; x += cap", "what the hell is + supposed to do between a integer and a boolean 🐘🐧"},
+		{"y += 1", "bruh, you can't just arbitrarily assign to: \"y\" without defining it first 🙄"},
+		{"fr arr = [1]; arr[2] += 1", "this array only goes from 1-1, but you tried to grab 2 - that's way off! 📏"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+				}
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		case []int:
+			array, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Errorf("obj not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			for i, expectedElem := range expected {
+				testIntegerObject(t, array.Elements[i], int64(expectedElem))
+			}
+		}
+	}
+}
+
 func TestForStatement(t *testing.T) {
 	input := `
 		fr items = [1, 2, 3, 4];
@@ -939,6 +1769,124 @@ func TestForStatementWithHash(t *testing.T) {
 	testIntegerObject(t, result, 3)
 }
 
+func TestForStatementWithString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fr out = ""; stalk (c in "abc") { out = c + out } out`, "cba"},
+		{`fr out = []; stalk (c in "héy 🔥") { out = slide(out, c) } out`, "[h, é, y,  , 🔥]"},
+		{`fr n = 0; stalk (c in "") { n += 1 } n`, "0"},
+		{`fr out = ""; stalk (c in "hello") { vibe (c is "l") { pass } out += c } out`, "heo"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestForStatementWithKeyAndValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fr out = ""; stalk (k, v in {"a": 1, "b": 2}) { out += k + v } out`, "a1b2"},
+		{`fr out = ""; stalk (i, x in ["x", "y", "z"]) { out += i + x } out`, "1x2y3z"},
+		{`fr out = ""; stalk (i, c in "hi") { out += i + c } out`, "1h2i"},
+		{`fr out = ""; stalk (i, n in 10..6 by -2) { out += i + ":" + n + " " } out`, "1:10 2:8 3:6 "},
+		{`fr out = ""; stalk (k in {"a": 1, "b": 2}) { out += k } out`, "ab"},
+		{`fr i = 100; stalk (i, x in [1]) { } i`, "100"},
+		{`stalk (k, v in 5) { }`, "1:1: integer can't be looped over - try something iterable like an array, range, string or a hash 🌀"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestBlockScoping(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// fr inside a block shadows the outer variable and goes away after it
+		{`fr x = 1; vibe (noCap) { fr x = 2 } x`, "1"},
+		{`fr x = 1; vibe (cap) { } nvm { fr x = 2 } x`, "1"},
+		{`fr x = 1; vibe (cap) { } unless (noCap) { fr x = 2 } x`, "1"},
+		{`fr x = 1; vibe (noCap) { fr x = 2; x }`, "2"},
+		{`fr i = 0; fr x = 1; onRepeat (i < 3) { fr x = i; i += 1 } x`, "1"},
+		{`fr x = 1; stalk (n in [1, 2]) { fr x = n } x`, "1"},
+		// plain assignment still reaches the outer variable
+		{`fr x = 1; vibe (noCap) { x = 2 } x`, "2"},
+		{`fr x = 1; vibe (noCap) { vibe (noCap) { x += 5 } } x`, "6"},
+		{`fr i = 0; fr total = 0; onRepeat (i < 3) { i += 1; total += i } total`, "6"},
+		// every loop iteration starts with a fresh scope
+		{`fr i = 0; fr seen = ""; onRepeat (i < 2) { fr y = i; seen += y; i += 1 } seen`, "01"},
+		// names declared inside a block can't be seen outside it
+		{`vibe (noCap) { fr inner = 1 } inner`, "1:31: inner? never heard of them 🤷‍♀️"},
+		{`fr i = 0; onRepeat (i < 1) { fr inner = 1; i += 1 } inner`, "1:53: inner? never heard of them 🤷‍♀️"},
+		{`vibe (noCap) { fr inner = 1 } inner = 2`, "1:31: bruh, you can't just arbitrarily assign to: \"inner\" without defining it first 🙄"},
+		// closures keep the scope they were made in
+		{`fr f = ghosted; vibe (noCap) { fr secret = 42; f = cook() { secret } } f()`, "42"},
+		{`cook counter() { fr n = 0; yeet cook() { n += 1; n } }; fr c = counter(); c(); c()`, "2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestConstants(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`deadass x = 5; x`, "5"},
+		{`deadass x = 5; fr y = x * 2; y`, "10"},
+		{`deadass x = 5; vibe (noCap) { fr x = 6 } x`, "5"},
+		{`deadass x = 5; vibe (noCap) { deadass x = 6; x }`, "6"},
+		{`deadass arr = [1, 2]; arr[1] = 9; arr`, "[9, 2]"},
+		{`deadass cook twice(n) { n * 2 }; twice(4)`, "8"},
+		{`cook f() { 1 }; f = cook() { 2 }; f()`, "2"},
+		{`deadass x = 5; x = 6`, "1:16: x is deadass - you can't change it once it's set 🔒"},
+		{`deadass x = 5; x += 1`, "1:16: x is deadass - you can't change it once it's set 🔒"},
+		{`deadass x = 5; vibe (noCap) { x = 6 }`, "1:31: x is deadass - you can't change it once it's set 🔒"},
+		{`deadass x = 5; fr x = 6`, "1:16: x is deadass already - you can't declare it again 🔒"},
+		{`deadass x = 5; deadass x = 6`, "1:16: x is deadass already - you can't declare it again 🔒"},
+		{`deadass cook f() { 1 }; f = cook() { 2 }`, "1:25: f is deadass - you can't change it once it's set 🔒"},
+		{`deadass cook f() { 1 }; cook f() { 2 }`, "1:25: f is deadass already - you can't declare it again 🔒"},
+		{`cook outer() { deadass n = 1; n = 2 }; outer()`, "1:31: n is deadass - you can't change it once it's set 🔒"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	evaluated := testEval("deadass x = 5; x = 6")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+	if errObj.Code != diagnostic.ConstantReassignment {
+		t.Errorf("wrong code. got=%s", errObj.Code)
+	}
+	if strings.Join(errObj.Hints, "|") != "declare it with fr x = ... instead if it needs to change" {
+		t.Errorf("wrong hints. got=%q", errObj.Hints)
+	}
+}
+
 func TestForStatementWithBreak(t *testing.T) {
 	input := `
 		fr items = [1, 2, 3, 4];
@@ -1340,8 +2288,8 @@ func TestIndexExpressionAssignmentErrors(t *testing.T) {
 			"this array only goes from 1-3, but you tried to grab 5 - that's way off! 📏",
 		},
 		{
-			`fr arr = [1, 2, 3]; arr[-1] = 10;`,
-			"this array only goes from 1-3, but you tried to grab -1 - that's way off! 📏",
+			`fr arr = [1, 2, 3]; arr[-4] = 10;`,
+			"this array only goes from 1-3, but you tried to grab -4 - that's way off! 📏",
 		},
 		// Invalid types for index assignment
 		{
@@ -1505,6 +2453,145 @@ func TestIndexExpressionAssignmentInLoops(t *testing.T) {
 	}
 }
 
+func TestSelfReferencingInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fr a = [1, 2]; a[2] = a; a", "[1, [...]]"},
+		{`fr h = {"a": 1}; h["self"] = h; h`, "{a: 1, self: {...}}"},
+		{`fr a = [1]; fr h = {"list": a}; a[1] = h; a`, "[{list: [...]}]"},
+		{"fr a = [1, 2]; a[2] = a; glowUp(a, 5)", "[\n  1,\n  [...]\n]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestGlowUp(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`glowUp(5)`, "5"},
+		{`glowUp([1, 2, 3])`, "[1, 2, 3]"},
+		{`glowUp({"a": [1, 2], "b": "hi"}, 12)`, "{\n  a: [1, 2],\n  b: hi\n}"},
+		{`glowUp([[1, 2], [3, 4]], 10)`, "[\n  [1, 2],\n  [3, 4]\n]"},
+		{`glowUp()`, "glowUp needs 1 or 2 arguments but you gave it 0 🥲"},
+		{`glowUp(1, "wide")`, "glowUp needs a whole number for the width, not string 📏"},
+		{`glowUp(1, 0)`, "glowUp can't fit anything in a width of 0 📏"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if str, ok := evaluated.(*object.String); ok {
+			if str.Value != tt.expected {
+				t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, str.Value)
+			}
+			continue
+		}
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("object is not String or Error. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestNegativeIndexing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][-3]", 1},
+		{`"hello"[-1]`, "o"},
+		{`"héllo"[-4]`, "é"},
+		{"(1..10 by 3)[-1]", 10},
+		{"fr arr = [1, 2, 3]; arr[-1] = 10; arr[3]", 10},
+		{"fr arr = [1, 2, 3]; arr[-2] += 5; arr[2]", 7},
+		{"[1, 2, 3][-4]", errorMessage("this array only goes from 1-3, but you tried to grab -4 - that's way off! 📏")},
+		{"[1, 2, 3][0]", errorMessage("this array only goes from 1-3, but you tried to grab 0 - that's way off! 📏")},
+		{`""[-1]`, errorMessage("this string only goes from 1-0, but you tried to grab -1 - that's way off! 📏")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testStringObject(t, evaluated, expected)
+		case errorMessage:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != string(expected) {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"[1, 2, 3, 4, 5][2:4]", "[2, 3, 4]"},
+		{"[1, 2, 3, 4, 5][:2]", "[1, 2]"},
+		{"[1, 2, 3, 4, 5][4:]", "[4, 5]"},
+		{"[1, 2, 3, 4, 5][-2:]", "[4, 5]"},
+		{"[1, 2, 3, 4, 5][2:-2]", "[2, 3, 4]"},
+		{"[1, 2, 3][:]", "[1, 2, 3]"},
+		{"[1, 2, 3][3:2]", "[]"},
+		{"[1, 2, 3][4:]", "[]"},
+		{"[][:]", "[]"},
+		{"fr a = [1, 2, 3]; fr b = a[:]; b[1] = 9; a", "[1, 2, 3]"},
+		{`"hello"[2:4]`, "ell"},
+		{`"héllo"[-3:]`, "llo"},
+		{`"hello"[:1]`, "h"},
+		{"(1..10)[2:4]", "2..4"},
+		{"(1..10 by 2)[-2:]", "7..9 by 2"},
+		{"count((1..10)[3:2])", "0"},
+		{"fr a = ghosted; a?[1:2]", "ghosted"},
+		{"[1, 2, 3][5:]", errorMessage("this array only goes from 1-3, but you tried to slice 5:3 - that's way off! 📏")},
+		{"[1, 2, 3][0:2]", errorMessage("this array only goes from 1-3, but you tried to slice 0:2 - that's way off! 📏")},
+		{`"abc"[1:9]`, errorMessage("this string only goes from 1-3, but you tried to slice 1:9 - that's way off! 📏")},
+		{`[1, 2][1:"two"]`, errorMessage("hey you can only use [] with whole numbers, string aint it")},
+		{"5[1:2]", errorMessage("you can't slice a integer ✂️")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, expected, evaluated.Inspect())
+			}
+		case errorMessage:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != string(expected) {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
 func TestCaughtIn4KLogging(t *testing.T) {
 	tests := []struct {
 		input        string
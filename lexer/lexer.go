@@ -1,66 +1,178 @@
 package lexer
 
 import (
+	"fmt"
+	"nocap/diagnostic"
 	"nocap/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Lexer struct {
 	input        string
-	position     int  // current position in input (points to current char)
-	readPosition int  // current reading position in input (after current char)
-	ch           byte // current char under examination
+	file         string
+	position     int  // current byte offset in input (points to current char)
+	readPosition int  // current reading byte offset in input (after current char)
+	ch           rune // current char under examination
+	line         int  // line of the current char
+	column       int  // column of the current char, counted in characters
+	diagnostics  []diagnostic.Diagnostic
+
+	// interpolations tracks the ${...} blocks inside strings that are still open,
+	// innermost last
+	interpolations []interpolation
+}
+
+type interpolation struct {
+	start  token.Position // where the enclosing string started
+	braces int            // number of { opened inside the ${...} and not yet closed
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewWithFilename("", input)
+}
+
+// NewWithFilename creates a lexer whose token positions report the given file name
+func NewWithFilename(filename, input string) *Lexer {
+	l := &Lexer{input: input, file: filename, line: 1}
 	l.readChar()
 	return l
 }
 
+// Diagnostics returns the problems found while scanning so far, such as
+// strings or comments that never end
+func (l *Lexer) Diagnostics() []diagnostic.Diagnostic {
+	return l.diagnostics
+}
+
+// Errors returns the same problems as Diagnostics, formatted as text
+func (l *Lexer) Errors() []string {
+	return diagnostic.Strings(l.diagnostics)
+}
+
+// errorAt records a problem that starts at pos and runs up to the
+// character being looked at
+func (l *Lexer) errorAt(code diagnostic.Code, pos token.Position, format string, a ...interface{}) {
+	end := l.pos()
+	if end.Line < pos.Line || end.Line == pos.Line && end.Column < pos.Column {
+		end = pos
+	}
+
+	l.diagnostics = append(l.diagnostics, diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		Code:     code,
+		Span:     token.Span{Start: pos, End: end},
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
 
+	start := l.pos()
+
 	switch l.ch {
 	case '=':
 		tok = newToken(token.ASSIGN, l.ch)
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.PLUS_ASSIGN)
+		} else {
+			tok = newToken(token.PLUS, l.ch)
+		}
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.MINUS_ASSIGN)
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '/':
 		if l.peekChar() == '/' {
 			l.readSingleLineComment()
 			return l.NextToken()
 		} else if l.peekChar() == '*' {
-			l.readMultiLineComment()
+			l.readMultiLineComment(start)
 			return l.NextToken()
+		} else if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.SLASH_ASSIGN)
 		} else {
 			tok = newToken(token.SLASH, l.ch)
 		}
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.ASTERISK_ASSIGN)
+		} else if l.peekChar() == '*' {
+			tok = l.newTwoCharToken(token.POWER)
+		} else {
+			tok = newToken(token.ASTERISK, l.ch)
+		}
 	case '<':
 		if l.peekChar() == '=' {
-			ch := l.ch
-			l.readChar()
-			literal := string(ch) + string(l.ch)
-			tok = token.Token{Type: token.LTE, Literal: literal}
+			tok = l.newTwoCharToken(token.LTE)
+		} else if l.peekChar() == '<' {
+			tok = l.newTwoCharToken(token.SHIFT_LEFT)
 		} else {
 			tok = newToken(token.LT, l.ch)
 		}
 	case '>':
 		if l.peekChar() == '=' {
-			ch := l.ch
-			l.readChar()
-			literal := string(ch) + string(l.ch)
-			tok = token.Token{Type: token.GTE, Literal: literal}
+			tok = l.newTwoCharToken(token.GTE)
+		} else if l.peekChar() == '>' {
+			tok = l.newTwoCharToken(token.SHIFT_RIGHT)
 		} else {
 			tok = newToken(token.GT, l.ch)
 		}
 	case '%':
-		tok = newToken(token.MODULO, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.MODULO_ASSIGN)
+		} else {
+			tok = newToken(token.MODULO, l.ch)
+		}
+	case '~':
+		if l.peekChar() == '/' {
+			tok = l.newTwoCharToken(token.FLOOR_DIV)
+		} else {
+			tok = newToken(token.BIT_NOT, l.ch)
+		}
+	case '?':
+		switch l.peekChar() {
+		case '?':
+			tok = l.newTwoCharToken(token.COALESCE)
+		case '[':
+			tok = l.newTwoCharToken(token.OPTIONAL_LBRACKET)
+		default:
+			tok = token.Token{Type: token.ILLEGAL, Literal: string(l.ch)}
+			l.errorAt(diagnostic.UnknownCharacter, start, "a lone ? doesn't do anything - did you mean ?? or ?[ 🤔")
+		}
+	case '&':
+		tok = newToken(token.BIT_AND, l.ch)
+	case '|':
+		if l.peekChar() == '>' {
+			tok = l.newTwoCharToken(token.PIPE)
+		} else {
+			tok = newToken(token.BIT_OR, l.ch)
+		}
+	case '^':
+		tok = newToken(token.BIT_XOR, l.ch)
+	case '.':
+		if l.peekChar() == '.' && l.peekSecondChar() == '.' {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else if l.peekChar() == '.' {
+			tok = l.newTwoCharToken(token.DOTDOT)
+		} else if isDigit(l.peekChar()) {
+			tok = l.readNumber()
+			tok.Start, tok.End = start, l.pos()
+			return tok
+		} else {
+			tok = token.Token{Type: token.ILLEGAL, Literal: string(l.ch)}
+			l.errorAt(diagnostic.UnknownCharacter, start, "wtf is %q doing here? i don't know that character 🤨", tok.Literal)
+		}
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
 	case ':':
@@ -68,16 +180,35 @@ func (l *Lexer) NextToken() token.Token {
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case '{':
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1].braces += 1
+		}
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
-		tok = newToken(token.RBRACE, l.ch)
+		if n := len(l.interpolations); n > 0 && l.interpolations[n-1].braces == 0 {
+			// this closes a ${...}, so carry on with the rest of the string
+			stringStart := l.interpolations[n-1].start
+			l.interpolations = l.interpolations[:n-1]
+			tok = l.readStringToken(stringStart, true)
+		} else {
+			if n > 0 {
+				l.interpolations[n-1].braces -= 1
+			}
+			tok = newToken(token.RBRACE, l.ch)
+		}
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
 		tok = newToken(token.RPAREN, l.ch)
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok = l.readStringToken(start, false)
+	case '`':
+		offset := l.position
+		if str, ok := l.readRawString(start); ok {
+			tok = token.Token{Type: token.STRING, Literal: str}
+		} else {
+			tok = token.Token{Type: token.ILLEGAL, Literal: l.input[offset:l.position]}
+		}
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
@@ -85,23 +216,33 @@ func (l *Lexer) NextToken() token.Token {
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
+		tok.Start, tok.End = start, start
+		return tok
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Start, tok.End = start, l.pos()
 			return tok
-		} else if isDigitOrDecimal(l.ch) {
+		} else if isDigit(l.ch) {
 			tok = l.readNumber()
+			tok.Start, tok.End = start, l.pos()
 			return tok
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			tok = token.Token{Type: token.ILLEGAL, Literal: l.input[l.position:l.readPosition]}
+			l.errorAt(diagnostic.UnknownCharacter, start, "wtf is %q doing here? i don't know that character 🤨", tok.Literal)
 		}
 	}
 
 	l.readChar()
+	tok.Start, tok.End = start, l.pos()
 	return tok
 }
 
+func (l *Lexer) pos() token.Position {
+	return token.Position{File: l.file, Line: l.line, Column: l.column}
+}
+
 func (l *Lexer) skipWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
 		l.readChar()
@@ -109,44 +250,246 @@ func (l *Lexer) skipWhitespace() {
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 1
+	} else {
+		l.column += 1
+	}
+
+	width := 1
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.ch, width = utf8.DecodeRuneInString(l.input[l.readPosition:])
 	}
 	l.position = l.readPosition
-	l.readPosition += 1
+	l.readPosition += width
 }
 
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) || isDigit(l.ch) {
+	for isIdentifierChar(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
+// readNumber reads an integer or float literal. Besides plain decimals like
+// 42, 4.2 and .42 it understands 0x, 0o and 0b prefixed integers, exponents
+// such as 6.02e23 and _ separators between digits like 1_000_000. Malformed
+// numbers are reported and come back as a single ILLEGAL token.
 func (l *Lexer) readNumber() token.Token {
 	position := l.position
 	tokType := token.TokenType(token.INT)
-	for isDigitOrDecimal(l.ch) {
-		if l.ch == '.' {
+
+	var problem *numberProblem
+
+	if base, ok := numberBases[l.peekChar()]; ok && l.ch == '0' {
+		l.readChar()
+		l.readChar()
+
+		problem = l.readDigits(base.isDigit)
+		if problem == nil && l.position == position+2 {
+			problem = &numberProblem{l.pos(), fmt.Sprintf("%s numbers need at least one digit after the 0%c", base.name, l.input[position+1])}
+		}
+		if problem == nil && isIdentifierChar(l.ch) {
+			problem = &numberProblem{l.pos(), fmt.Sprintf("%q is not a valid %s digit", l.ch, base.name)}
+		}
+	} else {
+		problem = l.readDigits(isDigit)
+
+		if problem == nil && l.ch == '.' && isDigit(l.peekChar()) {
 			tokType = token.FLOAT
+			l.readChar()
+			problem = l.readDigits(isDigit)
 		}
-		l.readChar()
+
+		if problem == nil && (l.ch == 'e' || l.ch == 'E') {
+			sign := l.peekChar() == '+' || l.peekChar() == '-'
+			if isDigit(l.peekChar()) || sign && isDigit(l.peekSecondChar()) {
+				tokType = token.FLOAT
+				l.readChar()
+				if sign {
+					l.readChar()
+				}
+				problem = l.readDigits(isDigit)
+			} else {
+				problem = &numberProblem{l.pos(), "the exponent needs some digits, like 1e+5"}
+			}
+		}
+
+		if problem == nil && l.ch == '.' && isDigit(l.peekChar()) {
+			problem = &numberProblem{l.pos(), "a number can only have one decimal point"}
+		}
+		if problem == nil && isIdentifierChar(l.ch) {
+			problem = &numberProblem{l.pos(), fmt.Sprintf("%q can't come right after the digits", l.ch)}
+		}
+	}
+
+	if problem != nil {
+		// swallow the rest of the malformed number so it's reported only once
+		for isIdentifierChar(l.ch) || l.ch == '.' && isDigit(l.peekChar()) {
+			l.readChar()
+		}
+
+		literal := l.input[position:l.position]
+		l.errorAt(diagnostic.MalformedNumber, problem.pos, "%s is not a valid number: %s 🔢", literal, problem.reason)
+		return token.Token{Type: token.ILLEGAL, Literal: literal}
 	}
+
 	return token.Token{Type: tokType, Literal: l.input[position:l.position]}
 }
 
-func (l *Lexer) readString() string {
+type numberProblem struct {
+	pos    token.Position
+	reason string
+}
+
+type numberBase struct {
+	name    string
+	isDigit func(rune) bool
+}
+
+var numberBases = map[rune]numberBase{
+	'x': {"hex", isHexDigit},
+	'X': {"hex", isHexDigit},
+	'o': {"octal", isOctalDigit},
+	'O': {"octal", isOctalDigit},
+	'b': {"binary", isBinaryDigit},
+	'B': {"binary", isBinaryDigit},
+}
+
+// readDigits reads a run of digits that may be separated by single underscores
+func (l *Lexer) readDigits(isDigit func(rune) bool) *numberProblem {
+	for isDigit(l.ch) || l.ch == '_' {
+		if l.ch == '_' && !isDigit(l.peekChar()) {
+			return &numberProblem{l.pos(), "_ can only go between two digits"}
+		}
+		l.readChar()
+	}
+	return nil
+}
+
+// readStringToken reads a double quoted string, or the part of one that
+// follows a closed ${...} when resuming is set. Strings containing ${ are
+// split into TEMPLATE_* tokens with the embedded expressions lexed normally
+// in between.
+func (l *Lexer) readStringToken(start token.Position, resuming bool) token.Token {
+	offset := l.position
+	str, end := l.readString(start)
+
+	switch {
+	case end == 0:
+		return token.Token{Type: token.ILLEGAL, Literal: l.input[offset:l.position]}
+	case end == '{':
+		l.interpolations = append(l.interpolations, interpolation{start: start})
+		if resuming {
+			return token.Token{Type: token.TEMPLATE_MIDDLE, Literal: str}
+		}
+		return token.Token{Type: token.TEMPLATE_HEAD, Literal: str}
+	case resuming:
+		return token.Token{Type: token.TEMPLATE_TAIL, Literal: str}
+	default:
+		return token.Token{Type: token.STRING, Literal: str}
+	}
+}
+
+// readString reads string characters up to the closing quote or the next ${,
+// resolving escape sequences along the way. It leaves the lexer on the last
+// character read and returns it, or 0 if the string never ends.
+func (l *Lexer) readString(start token.Position) (string, rune) {
+	var out strings.Builder
+
+	for {
+		l.readChar()
+
+		switch l.ch {
+		case '"':
+			return out.String(), l.ch
+		case 0:
+			l.errorAt(diagnostic.UnterminatedString, start, "this string never ends - you forgot the closing \" 🧵")
+			return "", 0
+		case '$':
+			if l.peekChar() == '{' {
+				l.readChar()
+				return out.String(), l.ch
+			}
+			out.WriteRune(l.ch)
+		case '\\':
+			l.readEscape(&out)
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+// readEscape reads the escape sequence starting at the current backslash and
+// writes the character it stands for
+func (l *Lexer) readEscape(out *strings.Builder) {
+	pos := l.pos()
+	l.readChar()
+
+	switch l.ch {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '0':
+		out.WriteByte(0)
+	case '\\', '"', '`', '$':
+		out.WriteRune(l.ch)
+	case 'u':
+		if l.peekChar() != '{' {
+			l.errorAt(diagnostic.InvalidEscape, pos, "unicode escapes look like \\u{1F525}, you're missing the { 🧐")
+			return
+		}
+		l.readChar()
+
+		var hex strings.Builder
+		for l.peekChar() != '}' && l.peekChar() != '"' && l.peekChar() != 0 {
+			l.readChar()
+			hex.WriteRune(l.ch)
+		}
+
+		if l.peekChar() != '}' {
+			l.errorAt(diagnostic.InvalidEscape, pos, "unicode escapes look like \\u{1F525}, you're missing the } 🧐")
+			return
+		}
+		l.readChar()
+
+		code, err := strconv.ParseUint(hex.String(), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			l.errorAt(diagnostic.InvalidEscape, pos, "\\u{%s} is not a real character 🧐", hex.String())
+			return
+		}
+		out.WriteRune(rune(code))
+	case 0:
+		// the missing closing quote is reported by readString
+		return
+	default:
+		l.errorAt(diagnostic.InvalidEscape, pos, "idk what \\%c is supposed to mean - try \\n, \\t, \\\", \\\\ or \\u{...} 🧐", l.ch)
+		out.WriteRune(l.ch)
+	}
+}
+
+// readRawString reads a backtick string, which can span lines and takes every
+// character literally
+func (l *Lexer) readRawString(start token.Position) (string, bool) {
 	position := l.position + 1
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
-			break
+		if l.ch == '`' {
+			return l.input[position:l.position], true
+		}
+
+		if l.ch == 0 {
+			l.errorAt(diagnostic.UnterminatedString, start, "this string never ends - you forgot the closing ` 🧵")
+			return "", false
 		}
 	}
-	return l.input[position:l.position]
 }
 
 func (l *Lexer) readSingleLineComment() {
@@ -158,7 +501,7 @@ func (l *Lexer) readSingleLineComment() {
 	}
 }
 
-func (l *Lexer) readMultiLineComment() {
+func (l *Lexer) readMultiLineComment(start token.Position) {
 	l.readChar()
 	for {
 		l.readChar()
@@ -169,31 +512,74 @@ func (l *Lexer) readMultiLineComment() {
 		}
 
 		if l.ch == 0 {
+			l.errorAt(diagnostic.UnterminatedComment, start, "this comment never ends - close it with */ 💬")
 			break
 		}
 	}
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// isLetter reports whether ch can start an identifier. Besides ASCII letters
+// and '_' this accepts any Unicode letter and symbols such as emoji.
+func isLetter(ch rune) bool {
+	if ch < utf8.RuneSelf {
+		return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+	}
+	return ch != utf8.RuneError && (unicode.IsLetter(ch) || unicode.Is(unicode.So, ch))
 }
 
-func isDigit(ch byte) bool {
+// isIdentifierChar reports whether ch can appear after the first character of
+// an identifier. Combining marks, skin tone modifiers and zero width joiners
+// are allowed so that accented letters and composed emoji stay in one piece.
+func isIdentifierChar(ch rune) bool {
+	if isLetter(ch) || isDigit(ch) {
+		return true
+	}
+	return ch >= utf8.RuneSelf && (unicode.IsMark(ch) || unicode.IsDigit(ch) ||
+		unicode.Is(unicode.Sk, ch) || ch == '\u200d')
+}
+
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-func isDigitOrDecimal(ch byte) bool {
-	return ('0' <= ch && ch <= '9') || ch == '.'
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+func isOctalDigit(ch rune) bool {
+	return '0' <= ch && ch <= '7'
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func isBinaryDigit(ch rune) bool {
+	return ch == '0' || ch == '1'
+}
+
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
-func (l *Lexer) peekChar() byte {
+// newTwoCharToken makes a token out of the current and the next char,
+// leaving the lexer on the second one
+func (l *Lexer) newTwoCharToken(tokenType token.TokenType) token.Token {
+	ch := l.ch
+	l.readChar()
+	return token.Token{Type: tokenType, Literal: string(ch) + string(l.ch)}
+}
+
+func (l *Lexer) peekChar() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
 	} else {
-		return l.input[l.readPosition]
+		ch, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+		return ch
+	}
+}
+
+func (l *Lexer) peekSecondChar() rune {
+	_, width := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	if l.readPosition+width >= len(l.input) {
+		return 0
 	}
+	ch, _ := utf8.DecodeRuneInString(l.input[l.readPosition+width:])
+	return ch
 }
@@ -289,10 +289,477 @@ func TestEdgeCasesForIdentifiersWithDigits(t *testing.T) {
 		{token.IDENT, "_1"},
 		{token.IDENT, "a_"},
 		{token.IDENT, "_a"},
+		{token.ILLEGAL, "1a"},
+		{token.ILLEGAL, "1.5a"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := `fr x = 10;
+  "hi" /* comment
+*/ y`
+
+	tests := []struct {
+		expectedLiteral string
+		startLine       int
+		startColumn     int
+		endLine         int
+		endColumn       int
+	}{
+		{"fr", 1, 1, 1, 3},
+		{"x", 1, 4, 1, 5},
+		{"=", 1, 6, 1, 7},
+		{"10", 1, 8, 1, 10},
+		{";", 1, 10, 1, 11},
+		{"hi", 2, 3, 2, 7},
+		{"y", 3, 4, 3, 5},
+		{"", 3, 5, 3, 5},
+	}
+
+	l := NewWithFilename("script.nocap", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Start.File != "script.nocap" {
+			t.Fatalf("tests[%d] - file wrong. got=%q", i, tok.Start.File)
+		}
+
+		if tok.Start.Line != tt.startLine || tok.Start.Column != tt.startColumn {
+			t.Fatalf("tests[%d] - start wrong. expected=%d:%d, got=%d:%d",
+				i, tt.startLine, tt.startColumn, tok.Start.Line, tok.Start.Column)
+		}
+
+		if tok.End.Line != tt.endLine || tok.End.Column != tt.endColumn {
+			t.Fatalf("tests[%d] - end wrong. expected=%d:%d, got=%d:%d",
+				i, tt.endLine, tt.endColumn, tok.End.Line, tok.End.Column)
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := `fr café = "crème brûlée"; 🔥 + 👩‍💻 naïve π2 @`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		column          int
+	}{
+		{token.LET, "fr", 1},
+		{token.IDENT, "café", 4},
+		{token.ASSIGN, "=", 9},
+		{token.STRING, "crème brûlée", 11},
+		{token.SEMICOLON, ";", 25},
+		{token.IDENT, "🔥", 27},
+		{token.PLUS, "+", 29},
+		{token.IDENT, "👩‍💻", 31},
+		{token.IDENT, "naïve", 35},
+		{token.IDENT, "π2", 41},
+		{token.ILLEGAL, "@", 44},
+		{token.EOF, "", 45},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Start.Column != tt.column {
+			t.Fatalf("tests[%d] - column wrong. expected=%d, got=%d",
+				i, tt.column, tok.Start.Column)
+		}
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	input := "\"line\\nbreak\" \"tab\\there\" \"say \\\"yo\\\"\" \"back\\\\slash\" \"\\u{1F525} lit\" \"\\$\" `raw \\n \"quotes\"\nsecond line`"
+
+	tests := []string{
+		"line\nbreak",
+		"tab\there",
+		`say "yo"`,
+		`back\slash`,
+		"🔥 lit",
+		"$",
+		"raw \\n \"quotes\"\nsecond line",
+	}
+
+	l := New(input)
+
+	for i, expected := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != token.STRING {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, token.STRING, tok.Type)
+		}
+
+		if tok.Literal != expected {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, expected, tok.Literal)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Fatalf("unexpected lexer errors: %v", l.Errors())
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedType  token.TokenType
+		expectedError string
+	}{
+		{
+			"fr x = \"never\nends",
+			token.ILLEGAL,
+			"1:8: this string never ends - you forgot the closing \" 🧵",
+		},
+		{
+			"`raw",
+			token.ILLEGAL,
+			"1:1: this string never ends - you forgot the closing ` 🧵",
+		},
+		{
+			"x /* open comment",
+			token.EOF,
+			"1:3: this comment never ends - close it with */ 💬",
+		},
+		{
+			`"bad \q escape"`,
+			token.STRING,
+			`1:6: idk what \q is supposed to mean - try \n, \t, \", \\ or \u{...} 🧐`,
+		},
+		{
+			`"\u{110000}"`,
+			token.STRING,
+			`1:2: \u{110000} is not a real character 🧐`,
+		},
+		{
+			"x @",
+			token.ILLEGAL,
+			"1:3: wtf is \"@\" doing here? i don't know that character 🤨",
+		},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+
+		var tok token.Token
+		for tok = l.NextToken(); tok.Type == token.IDENT || tok.Type == token.LET || tok.Type == token.ASSIGN; tok = l.NextToken() {
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("input %q - tokentype wrong. expected=%q, got=%q",
+				tt.input, tt.expectedType, tok.Type)
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Errorf("input %q - expected 1 error, got %d: %v", tt.input, len(errors), errors)
+			continue
+		}
+
+		if errors[0] != tt.expectedError {
+			t.Errorf("input %q - wrong error. expected=%q, got=%q",
+				tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	input := `"yo ${name}, you got ${count({"a": "${x}"})} items" "\${not this}" "${a}${b}"`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TEMPLATE_HEAD, "yo "},
+		{token.IDENT, "name"},
+		{token.TEMPLATE_MIDDLE, ", you got "},
+		{token.IDENT, "count"},
+		{token.LPAREN, "("},
+		{token.LBRACE, "{"},
+		{token.STRING, "a"},
+		{token.COLON, ":"},
+		{token.TEMPLATE_HEAD, ""},
+		{token.IDENT, "x"},
+		{token.TEMPLATE_TAIL, ""},
+		{token.RBRACE, "}"},
+		{token.RPAREN, ")"},
+		{token.TEMPLATE_TAIL, " items"},
+		{token.STRING, "${not this}"},
+		{token.TEMPLATE_HEAD, ""},
+		{token.IDENT, "a"},
+		{token.TEMPLATE_MIDDLE, ""},
+		{token.IDENT, "b"},
+		{token.TEMPLATE_TAIL, ""},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNumberForms(t *testing.T) {
+	input := `0xFF 0b1010 0o17 1_000_000 6.02e23 1E-5 2.5e+3 3e2 0.000_1 007 5.name 1..5 .5 ...rest`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "0xFF"},
+		{token.INT, "0b1010"},
+		{token.INT, "0o17"},
+		{token.INT, "1_000_000"},
+		{token.FLOAT, "6.02e23"},
+		{token.FLOAT, "1E-5"},
+		{token.FLOAT, "2.5e+3"},
+		{token.FLOAT, "3e2"},
+		{token.FLOAT, "0.000_1"},
+		{token.INT, "007"},
+		{token.INT, "5"},
+		{token.ILLEGAL, "."},
+		{token.IDENT, "name"},
 		{token.INT, "1"},
+		{token.DOTDOT, ".."},
+		{token.INT, "5"},
+		{token.FLOAT, ".5"},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "rest"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestMalformedNumbers(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"1.2.3", "1:4: 1.2.3 is not a valid number: a number can only have one decimal point 🔢"},
+		{"0x", "1:3: 0x is not a valid number: hex numbers need at least one digit after the 0x 🔢"},
+		{"0b102", "1:5: 0b102 is not a valid number: '2' is not a valid binary digit 🔢"},
+		{"0o78", "1:4: 0o78 is not a valid number: '8' is not a valid octal digit 🔢"},
+		{"0xFG", "1:4: 0xFG is not a valid number: 'G' is not a valid hex digit 🔢"},
+		{"1__000", "1:2: 1__000 is not a valid number: _ can only go between two digits 🔢"},
+		{"100_", "1:4: 100_ is not a valid number: _ can only go between two digits 🔢"},
+		{"1e+", "1:2: 1e is not a valid number: the exponent needs some digits, like 1e+5 🔢"},
+		{"1e", "1:2: 1e is not a valid number: the exponent needs some digits, like 1e+5 🔢"},
+		{"1.5Ex", "1:4: 1.5Ex is not a valid number: the exponent needs some digits, like 1e+5 🔢"},
+		{"123abc", "1:4: 123abc is not a valid number: 'a' can't come right after the digits 🔢"},
+		{"1e5x", "1:4: 1e5x is not a valid number: 'x' can't come right after the digits 🔢"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != token.ILLEGAL {
+			t.Errorf("input %q - tokentype wrong. expected=%q, got=%q",
+				tt.input, token.ILLEGAL, tok.Type)
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Errorf("input %q - expected 1 error, got %d: %v", tt.input, len(errors), errors)
+			continue
+		}
+
+		if errors[0] != tt.expectedError {
+			t.Errorf("input %q - wrong error. expected=%q, got=%q",
+				tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestCompoundAssignmentTokens(t *testing.T) {
+	input := `x += 1; x -= 2; x *= 3; x /= 4; x %= 5; x / 2 // done`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "x"}, {token.PLUS_ASSIGN, "+="}, {token.INT, "1"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.MINUS_ASSIGN, "-="}, {token.INT, "2"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.ASTERISK_ASSIGN, "*="}, {token.INT, "3"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.SLASH_ASSIGN, "/="}, {token.INT, "4"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.MODULO_ASSIGN, "%="}, {token.INT, "5"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.SLASH, "/"}, {token.INT, "2"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestPowerAndFloorDivisionTokens(t *testing.T) {
+	input := `2 ** 3 *= 4 ~/ 5 * 6`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "2"},
+		{token.POWER, "**"},
+		{token.INT, "3"},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.INT, "4"},
+		{token.FLOOR_DIV, "~/"},
+		{token.INT, "5"},
+		{token.ASTERISK, "*"},
+		{token.INT, "6"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestBitwiseTokens(t *testing.T) {
+	input := `a & b | c ^ ~d << 2 >> 1 <= >= ~/ |>`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
 		{token.IDENT, "a"},
-		{token.FLOAT, "1.5"},
+		{token.BIT_AND, "&"},
+		{token.IDENT, "b"},
+		{token.BIT_OR, "|"},
+		{token.IDENT, "c"},
+		{token.BIT_XOR, "^"},
+		{token.BIT_NOT, "~"},
+		{token.IDENT, "d"},
+		{token.SHIFT_LEFT, "<<"},
+		{token.INT, "2"},
+		{token.SHIFT_RIGHT, ">>"},
+		{token.INT, "1"},
+		{token.LTE, "<="},
+		{token.GTE, ">="},
+		{token.FLOOR_DIV, "~/"},
+		{token.PIPE, "|>"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNullSafeTokens(t *testing.T) {
+	input := `a ?? b?[1] ?`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
 		{token.IDENT, "a"},
+		{token.COALESCE, "??"},
+		{token.IDENT, "b"},
+		{token.OPTIONAL_LBRACKET, "?["},
+		{token.INT, "1"},
+		{token.RBRACKET, "]"},
+		{token.ILLEGAL, "?"},
 		{token.EOF, ""},
 	}
 
@@ -311,4 +778,9 @@ func TestEdgeCasesForIdentifiersWithDigits(t *testing.T) {
 				i, tt.expectedLiteral, tok.Literal)
 		}
 	}
+
+	expectedErrors := []string{"1:12: a lone ? doesn't do anything - did you mean ?? or ?[ 🤔"}
+	if len(l.Errors()) != len(expectedErrors) || l.Errors()[0] != expectedErrors[0] {
+		t.Fatalf("wrong lexer errors. expected=%q, got=%q", expectedErrors, l.Errors())
+	}
 }
@@ -1,6 +1,9 @@
 package object
 
-import "fmt"
+import (
+	"fmt"
+	"nocap/diagnostic"
+)
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
@@ -9,33 +12,64 @@ func NewEnclosedEnvironment(outer *Environment) *Environment {
 }
 
 func NewEnvironment() *Environment {
-	s := make(map[string]Object)
+	s := make(map[string]binding)
 	return &Environment{store: s, outer: nil}
 }
 
 type Environment struct {
-	store map[string]Object
+	store map[string]binding
 	outer *Environment
 	Logs  []string
 }
 
+// binding is a value with a name, and whether it was declared with deadass
+type binding struct {
+	value    Object
+	constant bool
+}
+
 func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
+	b, ok := e.store[name]
 	if !ok && e.outer != nil {
-		obj, ok = e.outer.Get(name)
+		return e.outer.Get(name)
 	}
-	return obj, ok
+	return b.value, ok
 }
 
+// Set declares a variable that can be reassigned later
 func (e *Environment) Set(name string, val Object) Object {
-	e.store[name] = val
+	return e.declare(name, val, false)
+}
+
+// SetConstant declares a variable that can never be reassigned
+func (e *Environment) SetConstant(name string, val Object) Object {
+	return e.declare(name, val, true)
+}
+
+func (e *Environment) declare(name string, val Object, constant bool) Object {
+	if b, ok := e.store[name]; ok && b.constant {
+		return &Error{
+			Message: fmt.Sprintf("%s is deadass already - you can't declare it again 🔒", name),
+			Code:    diagnostic.ConstantReassignment,
+			Hints:   []string{"pick a different name, or declare it inside a block to shadow it"},
+		}
+	}
+
+	e.store[name] = binding{value: val, constant: constant}
 	return val
 }
 
 func (e *Environment) Update(name string, val Object) Object {
-	_, ok := e.store[name]
+	b, ok := e.store[name]
 	if ok {
-		e.store[name] = val
+		if b.constant {
+			return &Error{
+				Message: fmt.Sprintf("%s is deadass - you can't change it once it's set 🔒", name),
+				Code:    diagnostic.ConstantReassignment,
+				Hints:   []string{fmt.Sprintf("declare it with fr %s = ... instead if it needs to change", name)},
+			}
+		}
+		e.store[name] = binding{value: val}
 		return val
 	}
 
@@ -43,7 +77,11 @@ func (e *Environment) Update(name string, val Object) Object {
 		return e.outer.Update(name, val)
 	}
 
-	return &Error{Message: fmt.Sprintf("bruh, you can't just arbitrarily assign to: %q without defining it first 🙄", name)}
+	return &Error{
+		Message: fmt.Sprintf("bruh, you can't just arbitrarily assign to: %q without defining it first 🙄", name),
+		Code:    diagnostic.UndefinedVariable,
+		Hints:   []string{fmt.Sprintf("use fr %s = ... the first time you give it a value", name)},
+	}
 }
 
 func (e *Environment) AddLogs(log string) {
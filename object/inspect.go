@@ -0,0 +1,111 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultPrettyWidth is how wide Pretty output can get before it starts
+// breaking arrays and hashes over several lines
+const DefaultPrettyWidth = 80
+
+// inspect prints o on one line. seen holds the arrays and hashes we are
+// currently inside of, so one that contains itself prints as [...] or
+// {...} instead of going round in circles forever.
+func inspect(o Object, seen map[Object]bool) string {
+	switch o := o.(type) {
+	case *Array:
+		if seen[o] {
+			return "[...]"
+		}
+		seen[o] = true
+		defer delete(seen, o)
+
+		elements := []string{}
+		for _, e := range o.Elements {
+			elements = append(elements, inspect(e, seen))
+		}
+
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *Hash:
+		if seen[o] {
+			return "{...}"
+		}
+		seen[o] = true
+		defer delete(seen, o)
+
+		pairs := []string{}
+		for _, pair := range o.pairs {
+			pairs = append(pairs, fmt.Sprintf("%s: %s",
+				inspect(pair.Key, seen), inspect(pair.Value, seen)))
+		}
+
+		return "{" + strings.Join(pairs, ", ") + "}"
+	default:
+		return o.Inspect()
+	}
+}
+
+// Pretty prints o like Inspect, but an array or hash that doesn't fit in
+// width columns gets one element per line, indented by two spaces
+func Pretty(o Object, width int) string {
+	var out bytes.Buffer
+	pretty(&out, o, 0, 0, width, map[Object]bool{})
+	return out.String()
+}
+
+// pretty writes o starting at column col, with nested lines indented by
+// indent spaces
+func pretty(out *bytes.Buffer, o Object, col, indent, width int, seen map[Object]bool) {
+	flat := inspect(o, seen)
+	if col+utf8.RuneCountInString(flat) <= width || seen[o] {
+		out.WriteString(flat)
+		return
+	}
+
+	inner := strings.Repeat(" ", indent+2)
+
+	switch o := o.(type) {
+	case *Array:
+		if len(o.Elements) == 0 {
+			out.WriteString(flat)
+			return
+		}
+		seen[o] = true
+		defer delete(seen, o)
+
+		out.WriteString("[\n")
+		for i, e := range o.Elements {
+			out.WriteString(inner)
+			pretty(out, e, indent+2, indent+2, width, seen)
+			if i < len(o.Elements)-1 {
+				out.WriteString(",")
+			}
+			out.WriteString("\n")
+		}
+		out.WriteString(strings.Repeat(" ", indent) + "]")
+	case *Hash:
+		if len(o.pairs) == 0 {
+			out.WriteString(flat)
+			return
+		}
+		seen[o] = true
+		defer delete(seen, o)
+
+		out.WriteString("{\n")
+		for i, pair := range o.pairs {
+			key := inspect(pair.Key, seen) + ": "
+			out.WriteString(inner + key)
+			pretty(out, pair.Value, indent+2+utf8.RuneCountInString(key), indent+2, width, seen)
+			if i < len(o.pairs)-1 {
+				out.WriteString(",")
+			}
+			out.WriteString("\n")
+		}
+		out.WriteString(strings.Repeat(" ", indent) + "}")
+	default:
+		out.WriteString(flat)
+	}
+}
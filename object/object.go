@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
 	"nocap/ast"
+	"nocap/diagnostic"
+	"nocap/token"
 	"strings"
 )
 
@@ -24,6 +27,7 @@ const (
 	BUILTIN_OBJ      = "builtin function"
 	ARRAY_OBJ        = "array"
 	HASH_OBJ         = "hash"
+	RANGE_OBJ        = "range"
 	BREAK_OBJ        = "bounce"
 	CONTINUE_OBJ     = "pass"
 )
@@ -33,7 +37,11 @@ type HashKey struct {
 	Value uint64
 }
 
+// Hashable objects can be used as hash keys. Objects that are equal as keys
+// must have the same HashKey, but different keys may share a HashKey too -
+// Hash compares the keys themselves to tell them apart.
 type Hashable interface {
+	Object
 	HashKey() HashKey
 }
 
@@ -58,11 +66,27 @@ type Float struct {
 
 func (f *Float) Type() ObjectType { return FLOAT_OBJ }
 func (f *Float) Inspect() string  { return fmt.Sprintf("%g", f.Value) }
+
+// HashKey makes whole floats like 1.0 the same key as the integer 1. Every
+// other float is keyed by its exact bits, and all NaNs are the same key.
 func (f *Float) HashKey() HashKey {
-	h := fnv.New64a()
-	h.Write([]byte(fmt.Sprintf("%f", f.Value)))
+	if i, ok := floatToInt64(f.Value); ok {
+		return (&Integer{Value: i}).HashKey()
+	}
 
-	return HashKey{Type: f.Type(), Value: h.Sum64()}
+	if math.IsNaN(f.Value) {
+		return HashKey{Type: f.Type(), Value: math.Float64bits(math.NaN())}
+	}
+
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
+// floatToInt64 converts f to an int64 if it is a whole number in range
+func floatToInt64(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
 }
 
 type Boolean struct {
@@ -102,13 +126,28 @@ func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
 type Error struct {
 	Message string
+	Code    diagnostic.Code
+	Span    token.Span // where in the script the error happened, if known
+	Hints   []string
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
-func (e *Error) Inspect() string  { return e.Message }
+func (e *Error) Inspect() string  { return e.Diagnostic().String() }
+
+// Diagnostic describes the error the same way the lexer and parser
+// describe theirs
+func (e *Error) Diagnostic() diagnostic.Diagnostic {
+	return diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		Code:     e.Code,
+		Span:     e.Span,
+		Message:  e.Message,
+		Hints:    e.Hints,
+	}
+}
 
 type Function struct {
-	Parameters []*ast.Identifier
+	Parameters []*ast.Parameter
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -156,19 +195,72 @@ type Array struct {
 }
 
 func (ao *Array) Type() ObjectType { return ARRAY_OBJ }
-func (ao *Array) Inspect() string {
-	var out bytes.Buffer
+func (ao *Array) Inspect() string  { return inspect(ao, map[Object]bool{}) }
+
+// Range is an inclusive run of whole numbers from Start to End. It never
+// stores its elements, so 1..1000000000 costs as much as 1..2
+type Range struct {
+	Start int64
+	End   int64
+	Step  int64
+}
 
-	elements := []string{}
-	for _, e := range ao.Elements {
-		elements = append(elements, e.Inspect())
+func (r *Range) Type() ObjectType { return RANGE_OBJ }
+func (r *Range) Inspect() string {
+	if r.Step == DefaultRangeStep(r.Start, r.End) {
+		return fmt.Sprintf("%d..%d", r.Start, r.End)
 	}
+	return fmt.Sprintf("%d..%d by %d", r.Start, r.End, r.Step)
+}
 
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
+// Len is the number of elements in the range, which is 0 when the step
+// points away from the end, like 1..10 by -1. Ranges spanning almost all of
+// int64 can hold more elements than an int64 counts, so Len stops at
+// math.MaxInt64; see TooLong
+func (r *Range) Len() int64 {
+	steps, ok := r.steps()
+	switch {
+	case !ok:
+		return 0
+	case steps >= math.MaxInt64:
+		return math.MaxInt64
+	default:
+		return int64(steps) + 1
+	}
+}
 
-	return out.String()
+// TooLong reports whether the range has more than math.MaxInt64 elements
+func (r *Range) TooLong() bool {
+	steps, ok := r.steps()
+	return ok && steps >= math.MaxInt64
+}
+
+// steps is how many times Step fits between Start and End, which is one
+// less than the number of elements. ok is false for empty ranges
+func (r *Range) steps() (uint64, bool) {
+	switch {
+	case r.Step > 0 && r.Start <= r.End:
+		return uint64(r.End-r.Start) / uint64(r.Step), true
+	case r.Step < 0 && r.Start >= r.End:
+		return uint64(r.Start-r.End) / -uint64(r.Step), true
+	default:
+		return 0, false
+	}
+}
+
+// At returns the element at the zero based index i, which must be less
+// than Len()
+func (r *Range) At(i int64) int64 {
+	return r.Start + i*r.Step
+}
+
+// DefaultRangeStep is the step used when a range doesn't say `by`: counting
+// up when start <= end and down otherwise
+func DefaultRangeStep(start, end int64) int64 {
+	if start > end {
+		return -1
+	}
+	return 1
 }
 
 type HashPair struct {
@@ -176,27 +268,170 @@ type HashPair struct {
 	Value Object
 }
 
+// Hash remembers the order keys were first added in, so looping over it
+// and printing it always give the same result
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	pairs   []HashPair        // in insertion order
+	buckets map[HashKey][]int // indexes into pairs of the keys with each HashKey
 }
 
-func (h *Hash) Type() ObjectType { return HASH_OBJ }
-func (h *Hash) Inspect() string {
-	var out bytes.Buffer
+func NewHash() *Hash {
+	return &Hash{buckets: make(map[HashKey][]int)}
+}
 
-	pairs := []string{}
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s",
-			pair.Key.Inspect(), pair.Value.Inspect()))
+func (h *Hash) find(key Hashable) (int, bool) {
+	for _, i := range h.buckets[key.HashKey()] {
+		if KeysEqual(h.pairs[i].Key, key) {
+			return i, true
+		}
 	}
+	return 0, false
+}
 
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
+func (h *Hash) Get(key Hashable) (HashPair, bool) {
+	if i, ok := h.find(key); ok {
+		return h.pairs[i], true
+	}
+	return HashPair{}, false
+}
 
-	return out.String()
+// Set adds or replaces a pair. Replacing a key keeps its original place
+// and its original key, so setting 1.0 on a hash that has 1 keeps 1.
+func (h *Hash) Set(key Hashable, value Object) {
+	if i, ok := h.find(key); ok {
+		h.pairs[i].Value = value
+		return
+	}
+
+	if h.buckets == nil {
+		h.buckets = make(map[HashKey][]int)
+	}
+
+	hashKey := key.HashKey()
+	h.buckets[hashKey] = append(h.buckets[hashKey], len(h.pairs))
+	h.pairs = append(h.pairs, HashPair{Key: key, Value: value})
+}
+
+func (h *Hash) Len() int { return len(h.pairs) }
+
+// Pairs returns every pair in insertion order
+func (h *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, len(h.pairs))
+	copy(pairs, h.pairs)
+	return pairs
 }
 
+// KeysEqual reports whether a and b are the same hash key. Numbers are
+// compared by value, so 1 and 1.0 are the same key.
+func KeysEqual(a, b Object) bool {
+	switch a := a.(type) {
+	case *Integer:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value == b.Value
+		case *Float:
+			i, ok := floatToInt64(b.Value)
+			return ok && i == a.Value
+		}
+	case *Float:
+		switch b := b.(type) {
+		case *Integer:
+			return KeysEqual(b, a)
+		case *Float:
+			return a.Value == b.Value || math.IsNaN(a.Value) && math.IsNaN(b.Value)
+		}
+	case *String:
+		if b, ok := b.(*String); ok {
+			return a.Value == b.Value
+		}
+	case *Boolean:
+		if b, ok := b.(*Boolean); ok {
+			return a.Value == b.Value
+		}
+	}
+
+	return a == b
+}
+
+// Equal reports whether a and b hold the same value. Arrays are equal when
+// their elements are equal in order, hashes when they have the same keys
+// with equal values in any order. Functions are only equal to themselves.
+func Equal(a, b Object) bool {
+	return equal(a, b, map[[2]Object]bool{})
+}
+
+// equal keeps track of the pairs it is already comparing, so an array that
+// contains itself doesn't send it round in circles forever
+func equal(a, b Object, seen map[[2]Object]bool) bool {
+	switch a := a.(type) {
+	case *Integer:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value == b.Value
+		case *Float:
+			return float64(a.Value) == b.Value
+		}
+	case *Float:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value == float64(b.Value)
+		case *Float:
+			return a.Value == b.Value
+		}
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+	case *Null:
+		_, ok := b.(*Null)
+		return ok
+	case *Range:
+		b, ok := b.(*Range)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		return a.Len() == 0 || a.Start == b.Start && (a.Len() == 1 || a.Step == b.Step)
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		if seen[[2]Object{a, b}] {
+			return true
+		}
+		seen[[2]Object{a, b}] = true
+		for i := range a.Elements {
+			if !equal(a.Elements[i], b.Elements[i], seen) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		b, ok := b.(*Hash)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		if seen[[2]Object{a, b}] {
+			return true
+		}
+		seen[[2]Object{a, b}] = true
+		for _, pair := range a.pairs {
+			other, ok := b.Get(pair.Key.(Hashable))
+			if !ok || !equal(pair.Value, other.Value, seen) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+func (h *Hash) Type() ObjectType { return HASH_OBJ }
+func (h *Hash) Inspect() string  { return inspect(h, map[Object]bool{}) }
+
 type Break struct{}
 
 func (b *Break) Type() ObjectType { return BREAK_OBJ }
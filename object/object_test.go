@@ -1,6 +1,9 @@
 package object
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -58,3 +61,201 @@ func TestIntegerHashKey(t *testing.T) {
 		t.Errorf("integers with twoerent content have same hash keys")
 	}
 }
+
+func TestHashKeepsInsertionOrder(t *testing.T) {
+	hash := &Hash{}
+	for _, key := range []string{"c", "a", "b", "a"} {
+		str := &String{Value: key}
+		hash.Set(str, &Integer{Value: int64(hash.Len())})
+	}
+
+	if hash.Len() != 3 {
+		t.Fatalf("hash has wrong length. got=%d", hash.Len())
+	}
+
+	if hash.Inspect() != "{c: 0, a: 3, b: 2}" {
+		t.Errorf("hash has wrong order. got=%q", hash.Inspect())
+	}
+
+	pair, ok := hash.Get(&String{Value: "a"})
+	if !ok || pair.Value.Inspect() != "3" {
+		t.Errorf("wrong pair for a. got=%v, %v", pair, ok)
+	}
+}
+
+func TestNumericHashKeys(t *testing.T) {
+	if (&Integer{Value: 1}).HashKey() != (&Float{Value: 1.0}).HashKey() {
+		t.Errorf("1 and 1.0 have different hash keys")
+	}
+
+	if (&Float{Value: 0.0000001}).HashKey() == (&Float{Value: 0.0000002}).HashKey() {
+		t.Errorf("0.0000001 and 0.0000002 have the same hash key")
+	}
+
+	if (&Float{Value: math.NaN()}).HashKey() != (&Float{Value: -math.NaN()}).HashKey() {
+		t.Errorf("NaNs have different hash keys")
+	}
+
+	tests := []struct {
+		a, b     Object
+		expected bool
+	}{
+		{&Integer{Value: 1}, &Float{Value: 1.0}, true},
+		{&Float{Value: 1.0}, &Integer{Value: 1}, true},
+		{&Integer{Value: 1}, &Float{Value: 1.5}, false},
+		{&Float{Value: 0.0000001}, &Float{Value: 0.0000002}, false},
+		{&Float{Value: math.NaN()}, &Float{Value: math.NaN()}, true},
+		{&String{Value: "1"}, &Integer{Value: 1}, false},
+		{&Boolean{Value: true}, &Integer{Value: 1}, false},
+		{&Boolean{Value: true}, &Boolean{Value: true}, true},
+	}
+
+	for _, tt := range tests {
+		if KeysEqual(tt.a, tt.b) != tt.expected {
+			t.Errorf("KeysEqual(%s %s, %s %s) wrong. expected=%t",
+				tt.a.Type(), tt.a.Inspect(), tt.b.Type(), tt.b.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestHashKeyCollisions(t *testing.T) {
+	keys := []Hashable{&String{Value: "a"}, &String{Value: "b"}, &Integer{Value: 3}, &Float{Value: 4.5}}
+
+	hash := NewHash()
+	for i, key := range keys {
+		hash.Set(key, &Integer{Value: int64(i + 1)})
+	}
+
+	// share one bucket between every key, like keys whose hashes collide
+	shared := []int{0, 1, 2, 3}
+	for _, key := range keys {
+		hash.buckets[key.HashKey()] = shared
+	}
+
+	hash.Set(&String{Value: "a"}, &Integer{Value: 5})
+	hash.Set(&Float{Value: 3.0}, &Integer{Value: 6})
+
+	if hash.Len() != 4 {
+		t.Fatalf("hash has wrong length. expected=4, got=%d (%s)", hash.Len(), hash.Inspect())
+	}
+
+	tests := []struct {
+		key      Hashable
+		expected string
+		found    bool
+	}{
+		{&String{Value: "a"}, "5", true},
+		{&String{Value: "b"}, "2", true},
+		{&Integer{Value: 3}, "6", true},
+		{&Float{Value: 3.0}, "6", true},
+		{&Float{Value: 4.5}, "4", true},
+		{&String{Value: "c"}, "", false},
+		{&Integer{Value: 4}, "", false},
+		{&String{Value: "3"}, "", false},
+	}
+
+	for _, tt := range tests {
+		pair, ok := hash.Get(tt.key)
+		if ok != tt.found {
+			t.Errorf("wrong lookup for %s %s. expected found=%t, got=%t", tt.key.Type(), tt.key.Inspect(), tt.found, ok)
+			continue
+		}
+		if ok && pair.Value.Inspect() != tt.expected {
+			t.Errorf("wrong pair for %s %s. expected=%s, got=%s", tt.key.Type(), tt.key.Inspect(), tt.expected, pair.Value.Inspect())
+		}
+	}
+}
+
+func TestInspectCycles(t *testing.T) {
+	arr := &Array{Elements: []Object{&Integer{Value: 1}, nil}}
+	arr.Elements[1] = arr
+
+	if arr.Inspect() != "[1, [...]]" {
+		t.Errorf("wrong inspect for array. got=%q", arr.Inspect())
+	}
+
+	hash := NewHash()
+	hash.Set(&String{Value: "a"}, &Integer{Value: 1})
+	hash.Set(&String{Value: "self"}, hash)
+	hash.Set(&String{Value: "list"}, arr)
+
+	if hash.Inspect() != "{a: 1, self: {...}, list: [1, [...]]}" {
+		t.Errorf("wrong inspect for hash. got=%q", hash.Inspect())
+	}
+
+	// the same array twice isn't a cycle
+	inner := &Array{Elements: []Object{&Integer{Value: 1}}}
+	twice := &Array{Elements: []Object{inner, inner}}
+
+	if twice.Inspect() != "[[1], [1]]" {
+		t.Errorf("wrong inspect for shared array. got=%q", twice.Inspect())
+	}
+}
+
+func TestPretty(t *testing.T) {
+	nested := NewHash()
+	nested.Set(&String{Value: "deep"}, &Array{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}}})
+	nested.Set(&String{Value: "x"}, &String{Value: "yyyyyyyyyyyy"})
+
+	hash := NewHash()
+	hash.Set(&String{Value: "name"}, &String{Value: "bestie"})
+	hash.Set(&String{Value: "nested"}, nested)
+	hash.Set(&String{Value: "empty"}, &Array{})
+
+	cycle := &Array{Elements: []Object{&Integer{Value: 1}, nil}}
+	cycle.Elements[1] = cycle
+
+	tests := []struct {
+		input    Object
+		width    int
+		expected string
+	}{
+		{&Integer{Value: 5}, 1, "5"},
+		{hash, 80, "{name: bestie, nested: {deep: [1, 2], x: yyyyyyyyyyyy}, empty: []}"},
+		{hash, 30, `{
+  name: bestie,
+  nested: {
+    deep: [1, 2],
+    x: yyyyyyyyyyyy
+  },
+  empty: []
+}`},
+		{cycle, 3, `[
+  1,
+  [...]
+]`},
+	}
+
+	for _, tt := range tests {
+		if got := Pretty(tt.input, tt.width); got != tt.expected {
+			t.Errorf("wrong output for width %d. expected=\n%s\ngot=\n%s", tt.width, tt.expected, got)
+		}
+	}
+}
+
+func TestRangeLenAtTheEdges(t *testing.T) {
+	tests := []struct {
+		rng     *Range
+		len     int64
+		tooLong bool
+	}{
+		{&Range{Start: 1, End: 10, Step: 1}, 10, false},
+		{&Range{Start: 1, End: 10, Step: -1}, 0, false},
+		{&Range{Start: 0, End: math.MaxInt64 - 1, Step: 1}, math.MaxInt64, false},
+		{&Range{Start: 0, End: math.MaxInt64, Step: 1}, math.MaxInt64, true},
+		{&Range{Start: -math.MaxInt64, End: math.MaxInt64, Step: 1}, math.MaxInt64, true},
+		{&Range{Start: math.MaxInt64, End: math.MinInt64, Step: -1}, math.MaxInt64, true},
+		{&Range{Start: math.MinInt64, End: math.MaxInt64, Step: 2}, math.MaxInt64, true},
+		{&Range{Start: math.MinInt64, End: math.MaxInt64, Step: 3}, 6148914691236517206, false},
+		{&Range{Start: math.MaxInt64, End: math.MinInt64, Step: math.MinInt64}, 2, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.rng.Len(); got != tt.len {
+			t.Errorf("%s has wrong length. expected=%d, got=%d", tt.rng.Inspect(), tt.len, got)
+		}
+		if got := tt.rng.TooLong(); got != tt.tooLong {
+			t.Errorf("%s has wrong TooLong. expected=%t, got=%t", tt.rng.Inspect(), tt.tooLong, got)
+		}
+	}
+}
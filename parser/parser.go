@@ -1,45 +1,77 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"nocap/ast"
+	"nocap/diagnostic"
 	"nocap/lexer"
 	"nocap/token"
 	"strconv"
+	"strings"
 )
 
 const (
 	_ int = iota
 	LOWEST
+	PIPE        // x |> f
+	COALESCE    // ??
 	OR          // or
 	AND         // and
 	EQUALS      // ==
 	LESSGREATER // > or < or <= or >=
+	RANGE       // 1..10
+	BIT_OR      // |
+	BIT_XOR     // ^
+	BIT_AND     // &
+	SHIFT       // << or >>
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
+	POWER       // ** (binds tighter than prefix operators, so -2 ** 2 is -4)
 	CALL        // myFunction(X)
 	INDEX       // array[index]
 	ASSIGN      // =
 )
 
 var precedences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.AND:      AND,
-	token.OR:       OR,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.LTE:      LESSGREATER,
-	token.GTE:      LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.MODULO:   PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
-	token.ASSIGN:   ASSIGN,
+	token.EQ:                EQUALS,
+	token.NOT_EQ:            EQUALS,
+	token.AND:               AND,
+	token.OR:                OR,
+	token.LT:                LESSGREATER,
+	token.GT:                LESSGREATER,
+	token.LTE:               LESSGREATER,
+	token.GTE:               LESSGREATER,
+	token.DOTDOT:            RANGE,
+	token.BIT_OR:            BIT_OR,
+	token.BIT_XOR:           BIT_XOR,
+	token.BIT_AND:           BIT_AND,
+	token.SHIFT_LEFT:        SHIFT,
+	token.SHIFT_RIGHT:       SHIFT,
+	token.PLUS:              SUM,
+	token.MINUS:             SUM,
+	token.SLASH:             PRODUCT,
+	token.ASTERISK:          PRODUCT,
+	token.MODULO:            PRODUCT,
+	token.FLOOR_DIV:         PRODUCT,
+	token.POWER:             POWER,
+	token.LPAREN:            CALL,
+	token.LBRACKET:          INDEX,
+	token.OPTIONAL_LBRACKET: INDEX,
+	token.COALESCE:          COALESCE,
+	token.PIPE:              PIPE,
+	token.ASSIGN:            ASSIGN,
+}
+
+// compoundAssignments maps compound assignment tokens to the operator they apply
+var compoundAssignments = map[token.TokenType]string{
+	token.PLUS_ASSIGN:     "+",
+	token.MINUS_ASSIGN:    "-",
+	token.ASTERISK_ASSIGN: "*",
+	token.SLASH_ASSIGN:    "/",
+	token.MODULO_ASSIGN:   "%",
 }
 
 type (
@@ -48,8 +80,11 @@ type (
 )
 
 type Parser struct {
-	l      *lexer.Lexer
-	errors []string
+	l           *lexer.Lexer
+	diagnostics []diagnostic.Diagnostic
+
+	lexerErrors int  // number of lexer diagnostics already copied into diagnostics
+	failed      bool // the statement being parsed has already hit an error
 
 	curToken  token.Token
 	peekToken token.Token
@@ -60,8 +95,8 @@ type Parser struct {
 
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
-		l:      l,
-		errors: []string{},
+		l:           l,
+		diagnostics: []diagnostic.Diagnostic{},
 	}
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
@@ -69,8 +104,10 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
 	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.TEMPLATE_HEAD, p.parseInterpolatedString)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.BIT_NOT, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.NULL, p.parseNullLiteral)
@@ -79,6 +116,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.ELLIPSIS, p.parseMisplacedSpread)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -86,6 +125,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
 	p.registerInfix(token.MODULO, p.parseInfixExpression)
+	p.registerInfix(token.FLOOR_DIV, p.parseInfixExpression)
+	p.registerInfix(token.POWER, p.parseInfixExpression)
 	p.registerInfix(token.AND, p.parseInfixExpression)
 	p.registerInfix(token.OR, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
@@ -94,9 +135,18 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.LTE, p.parseInfixExpression)
 	p.registerInfix(token.GTE, p.parseInfixExpression)
+	p.registerInfix(token.BIT_AND, p.parseInfixExpression)
+	p.registerInfix(token.BIT_OR, p.parseInfixExpression)
+	p.registerInfix(token.BIT_XOR, p.parseInfixExpression)
+	p.registerInfix(token.SHIFT_LEFT, p.parseInfixExpression)
+	p.registerInfix(token.SHIFT_RIGHT, p.parseInfixExpression)
+	p.registerInfix(token.COALESCE, p.parseInfixExpression)
+	p.registerInfix(token.PIPE, p.parsePipeExpression)
+	p.registerInfix(token.DOTDOT, p.parseRangeExpression)
 
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.OPTIONAL_LBRACKET, p.parseIndexExpression)
 
 	// Read two tokens, so curToken and peekToken are both set
 	p.nextToken()
@@ -108,6 +158,12 @@ func New(l *lexer.Lexer) *Parser {
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+
+	// Pick up anything the lexer complained about while reading the token
+	if lexerErrors := p.l.Diagnostics(); len(lexerErrors) > p.lexerErrors {
+		p.diagnostics = append(p.diagnostics, lexerErrors[p.lexerErrors:]...)
+		p.lexerErrors = len(lexerErrors)
+	}
 }
 
 func (p *Parser) curTokenIs(t token.TokenType) bool {
@@ -128,40 +184,182 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 	}
 }
 
+// Diagnostics returns every problem found by the lexer and the parser, in
+// the order they were found
+func (p *Parser) Diagnostics() []diagnostic.Diagnostic {
+	return p.diagnostics
+}
+
+// Errors returns the same problems as Diagnostics, formatted as text
 func (p *Parser) Errors() []string {
-	return p.errors
+	return diagnostic.Strings(p.diagnostics)
+}
+
+// errorAt records an error about the given token
+func (p *Parser) errorAt(code diagnostic.Code, tok token.Token, format string, a ...interface{}) {
+	p.report(tok, diagnostic.Diagnostic{
+		Code:    code,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+// report records a diagnostic about the given token. Only the first error in
+// a statement is kept, since anything after it is usually the parser
+// tripping over the same mistake again. ILLEGAL tokens have already been
+// reported by the lexer.
+func (p *Parser) report(tok token.Token, d diagnostic.Diagnostic) {
+	if p.failed || tok.Type == token.ILLEGAL {
+		p.failed = true
+		return
+	}
+	p.failed = true
+
+	d.Severity = diagnostic.Error
+	d.Span = tok.Span()
+	p.diagnostics = append(p.diagnostics, d)
+}
+
+// closers maps each closing delimiter to the one it closes
+var closers = map[token.TokenType]token.TokenType{
+	token.RPAREN:   token.LPAREN,
+	token.RBRACKET: token.LBRACKET,
+	token.RBRACE:   token.LBRACE,
 }
 
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("bruh I needed a %s, why did you hit me with a %s instead 🤦‍♀️",
-		t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	d := diagnostic.Diagnostic{
+		Code: diagnostic.UnexpectedToken,
+		Message: fmt.Sprintf("bruh I needed a %s, why did you hit me with a %s instead 🤦‍♀️",
+			t, p.peekToken.Type),
+		Expected: string(t),
+		Actual:   string(p.peekToken.Type),
+	}
+
+	if opener, ok := closers[t]; ok {
+		d.Hints = append(d.Hints, fmt.Sprintf("every %s needs a %s to close it", opener, t))
+	}
+
+	p.report(p.peekToken, d)
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("you can't lead with a %s - that's not how you begin things! 🤷‍♀️", t)
-	p.errors = append(p.errors, msg)
+	p.report(p.curToken, diagnostic.Diagnostic{
+		Code:    diagnostic.MissingExpression,
+		Message: fmt.Sprintf("you can't lead with a %s - that's not how you begin things! 🤷‍♀️", t),
+		Actual:  string(t),
+	})
 }
 
 func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
-	program.Statements = []ast.Statement{}
+	program.Statements = p.parseStatements(token.EOF)
 
-	for !p.curTokenIs(token.EOF) {
+	return program
+}
+
+// parseStatements parses statements until curToken is end or EOF. A
+// statement that fails to parse is dropped and the parser skips ahead to
+// the next statement, so one typo doesn't drown out every other error.
+func (p *Parser) parseStatements(end token.TokenType) []ast.Statement {
+	statements := []ast.Statement{}
+
+	for !p.curTokenIs(end) && !p.curTokenIs(token.EOF) {
+		outer := p.failed
+		p.failed = false
+
+		start := p.curToken.Start
 		stmt := p.parseStatement()
-		if stmt != nil {
-			program.Statements = append(program.Statements, stmt)
+		if p.failed {
+			if end == token.RBRACE && p.curTokenIs(token.RBRACE) {
+				// the broken statement ran into the } that closes this block
+				p.failed = outer
+				break
+			}
+			if isStatementKeyword(p.curToken.Type) && p.curToken.Start != start {
+				// the broken statement ran into the start of the next one,
+				// like fr a = followed by fr b = 1, so parse that one next
+				p.failed = outer
+				continue
+			}
+			p.synchronize()
+		} else if stmt != nil {
+			statements = append(statements, stmt)
 		}
+
+		p.failed = outer
 		p.nextToken()
 	}
 
-	return program
+	return statements
+}
+
+// synchronize skips the rest of a broken statement. It stops on the last
+// token of the statement - a ; or the token before a } or a keyword that
+// starts a new statement - so the caller's nextToken lands on the next one.
+func (p *Parser) synchronize() {
+	// open holds the brackets still open, innermost last
+	open := []token.TokenType{}
+
+	for !p.curTokenIs(token.EOF) {
+		switch p.curToken.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			open = append(open, p.curToken.Type)
+		case token.RPAREN:
+			open = closeBracket(open, token.LPAREN)
+		case token.RBRACKET:
+			open = closeBracket(open, token.LBRACKET)
+		case token.RBRACE:
+			open = closeBracket(open, token.LBRACE)
+		case token.SEMICOLON:
+			if len(open) == 0 {
+				return
+			}
+		}
+
+		// statements only live in blocks, so a keyword can't be inside
+		// a ( or [ - any left open before it were never closed
+		if isStatementKeyword(p.peekToken.Type) {
+			for len(open) > 0 && open[len(open)-1] != token.LBRACE {
+				open = open[:len(open)-1]
+			}
+		}
+
+		if len(open) == 0 && (p.peekTokenIs(token.RBRACE) || p.peekTokenIs(token.EOF) || isStatementKeyword(p.peekToken.Type)) {
+			return
+		}
+
+		p.nextToken()
+	}
+}
+
+// closeBracket pops the innermost bracket matching opener off open, along
+// with any unclosed ones inside it. A stray closer with no match is ignored.
+func closeBracket(open []token.TokenType, opener token.TokenType) []token.TokenType {
+	for i := len(open) - 1; i >= 0; i-- {
+		if open[i] == opener {
+			return open[:i]
+		}
+	}
+	return open
+}
+
+// isStatementKeyword reports whether t can only ever begin a statement,
+// which makes it a safe place to pick up parsing again after an error
+func isStatementKeyword(t token.TokenType) bool {
+	switch t {
+	case token.LET, token.CONST, token.RETURN, token.FOR, token.WHILE, token.CONTINUE, token.BREAK:
+		return true
+	default:
+		return false
+	}
 }
 
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.LET:
 		return p.parseLetStatement()
+	case token.CONST:
+		return p.parseConstStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
 	case token.FOR:
@@ -172,55 +370,34 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseContinueStatement()
 	case token.BREAK:
 		return p.parseBreakStatement()
+	case token.SEMICOLON:
+		// a stray ; like the one in `stalk (x in xs) { ... };` is an empty statement
+		return nil
 	case token.FUNCTION:
 		if p.peekTokenIs(token.IDENT) {
 			return p.parseFunctionStatement()
 		}
 		fallthrough
-	case token.IDENT:
-		if p.peekTokenIs(token.ASSIGN) {
-			return p.parseAssignmentStatement()
-		} else if p.isIndexExpressionAssignment() {
-			return p.parseIndexExpressionAssignmentStatement()
-		}
-		fallthrough
 	default:
 		return p.parseExpressionStatement()
 	}
 }
 
-func (p *Parser) isIndexExpressionAssignment() bool {
-	if !p.peekTokenIs(token.LBRACKET) {
-		return false
-	}
-
-	lexerCopy := *p.l
-	curToken := p.peekToken
-	peekToken := lexerCopy.NextToken()
-	bracketDepth := -1
-
-	for (bracketDepth != 0 && curToken.Type != token.EOF) || (peekToken.Type == token.LBRACKET || peekToken.Type == token.RBRACKET) {
-		curToken = peekToken
-		peekToken = lexerCopy.NextToken()
-		switch curToken.Type {
-		case token.LBRACKET:
-			bracketDepth -= 1
-		case token.RBRACKET:
-			bracketDepth += 1
-		}
-	}
-
-	curToken = peekToken
-	return curToken.Type == token.ASSIGN
+// peekTokenIsAssignment reports whether the peek token is = or a compound
+// assignment like +=
+func (p *Parser) peekTokenIsAssignment() bool {
+	_, compound := compoundAssignments[p.peekToken.Type]
+	return compound || p.peekTokenIs(token.ASSIGN)
 }
 
-func (p *Parser) parseAssignmentStatement() *ast.AssignmentStatement {
-	stmt := &ast.AssignmentStatement{Token: p.peekToken}
-	// curToken is IDENT
-	name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	stmt.Name = name
-	if !p.expectPeek(token.ASSIGN) {
-		return nil
+// parseAssignmentStatement parses the rest of `name = value` once the
+// identifier has been parsed and the = (or +=, -=, ...) is the peek token
+func (p *Parser) parseAssignmentStatement(name *ast.Identifier) *ast.AssignmentStatement {
+	p.nextToken()
+	stmt := &ast.AssignmentStatement{
+		Token:    p.curToken,
+		Name:     name,
+		Operator: compoundAssignments[p.curToken.Type],
 	}
 	p.nextToken()
 	stmt.Value = p.parseExpression(LOWEST)
@@ -258,24 +435,16 @@ func (p *Parser) parseFunctionStatement() *ast.FunctionStatement {
 	return stmt
 }
 
-func (p *Parser) parseIndexExpressionAssignmentStatement() ast.Statement {
-	stmt := &ast.IndexExpressionAssignmentStatement{}
-
-	// Parse the entire left-hand side expression (which could be complex index expressions)
-	leftExpr := p.parseExpression(LOWEST)
-
-	indexExpr, ok := leftExpr.(*ast.IndexExpression)
-	if !ok {
-		return nil
-	}
-
-	stmt.Left = indexExpr
-
-	if !p.expectPeek(token.ASSIGN) {
-		return nil
+// parseIndexExpressionAssignmentStatement parses the rest of `x[i] = value`
+// once the (possibly nested) index expression has been parsed and the = (or
+// +=, -=, ...) is the peek token
+func (p *Parser) parseIndexExpressionAssignmentStatement(left *ast.IndexExpression) *ast.IndexExpressionAssignmentStatement {
+	p.nextToken()
+	stmt := &ast.IndexExpressionAssignmentStatement{
+		Token:    p.curToken,
+		Left:     left,
+		Operator: compoundAssignments[p.curToken.Type],
 	}
-
-	stmt.Token = p.curToken
 	p.nextToken()
 
 	stmt.Value = p.parseExpression(LOWEST)
@@ -289,7 +458,6 @@ func (p *Parser) parseIndexExpressionAssignmentStatement() ast.Statement {
 
 func (p *Parser) parseContinueStatement() *ast.ContinueStatement {
 	stmt := &ast.ContinueStatement{Token: p.curToken}
-	p.nextToken()
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -298,7 +466,6 @@ func (p *Parser) parseContinueStatement() *ast.ContinueStatement {
 
 func (p *Parser) parseBreakStatement() *ast.BreakStatement {
 	stmt := &ast.BreakStatement{Token: p.curToken}
-	p.nextToken()
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -308,11 +475,11 @@ func (p *Parser) parseBreakStatement() *ast.BreakStatement {
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
+	stmt.Name = p.parsePattern()
+	if stmt.Name == nil {
 		return nil
 	}
-
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	p.checkPatternNames(stmt.Name)
 
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
@@ -329,6 +496,27 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
+// parseConstStatement parses deadass x = value and deadass cook name() {...},
+// which are parsed like fr and cook but can't be reassigned afterwards
+func (p *Parser) parseConstStatement() ast.Statement {
+	if p.peekTokenIs(token.FUNCTION) {
+		p.nextToken()
+		stmt := p.parseFunctionStatement()
+		if stmt == nil {
+			return nil
+		}
+		stmt.Constant = true
+		return stmt
+	}
+
+	stmt := p.parseLetStatement()
+	if stmt == nil {
+		return nil
+	}
+	stmt.Constant = true
+	return stmt
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 
@@ -350,11 +538,21 @@ func (p *Parser) parseForStatement() *ast.ForStatement {
 		return nil
 	}
 
-	if !p.expectPeek(token.IDENT) {
+	stmt.Key = p.parsePattern()
+	if stmt.Key == nil {
 		return nil
 	}
 
-	stmt.Key = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		stmt.Value = p.parsePattern()
+		if stmt.Value == nil {
+			return nil
+		}
+		p.checkPatternNames(stmt.Key, stmt.Value)
+	} else {
+		p.checkPatternNames(stmt.Key)
+	}
 
 	if !p.expectPeek(token.IN) {
 		return nil
@@ -401,11 +599,26 @@ func (p *Parser) parseWhileStatement() *ast.WhileStatement {
 	return stmt
 }
 
-func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+func (p *Parser) parseExpressionStatement() ast.Statement {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 
 	stmt.Expression = p.parseExpression(LOWEST)
 
+	// An identifier or index expression followed by = or += is an assignment
+	if p.peekTokenIsAssignment() {
+		switch left := stmt.Expression.(type) {
+		case *ast.Identifier:
+			return p.parseAssignmentStatement(left)
+		case *ast.IndexExpression:
+			if left.Optional {
+				p.errorAt(diagnostic.InvalidAssignmentTarget, left.Token, "you can't assign through ?[ - there might be nothing there to assign to 👻")
+			}
+			return p.parseIndexExpressionAssignmentStatement(left)
+		case *ast.SliceExpression:
+			p.errorAt(diagnostic.InvalidAssignmentTarget, p.peekToken, "you can't assign to a slice - change the items one at a time instead ✂️")
+		}
+	}
+
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -458,10 +671,21 @@ func (p *Parser) parseIdentifier() ast.Expression {
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
-	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	// The lexer has already checked the digits, so only the base and the
+	// separators need handling here. Leading zeros don't make a number octal.
+	literal := strings.ReplaceAll(p.curToken.Literal, "_", "")
+	base := 10
+	if len(literal) > 2 && literal[0] == '0' && strings.ContainsRune("xXoObB", rune(literal[1])) {
+		base = 0
+	}
+
+	value, err := strconv.ParseInt(literal, base, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		p.errorAt(diagnostic.NumberOutOfRange, p.curToken, "%s is too big for a 64 bit integer, the max is %d 🤯", p.curToken.Literal, int64(math.MaxInt64))
+		return nil
+	}
 	if err != nil {
-		msg := fmt.Sprintf("i was expecting a 64 bit integer but wtf is this: %q 🤮", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorAt(diagnostic.MalformedNumber, p.curToken, "i was expecting a 64 bit integer but wtf is this: %q 🤮", p.curToken.Literal)
 		return nil
 	}
 
@@ -473,10 +697,18 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 func (p *Parser) parseFloatLiteral() ast.Expression {
 	lit := &ast.FloatLiteral{Token: p.curToken}
 
-	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	value, err := strconv.ParseFloat(strings.ReplaceAll(p.curToken.Literal, "_", ""), 64)
+	if errors.Is(err, strconv.ErrRange) {
+		p.errorAt(diagnostic.NumberOutOfRange, p.curToken, "%s is too big for a 64 bit float 🤯", p.curToken.Literal)
+		return nil
+	}
 	if err != nil {
-		msg := fmt.Sprintf("i was expecting a 64 bit float but wtf is this: %q 🤮", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorAt(diagnostic.MalformedNumber, p.curToken, "i was expecting a 64 bit float but wtf is this: %q 🤮", p.curToken.Literal)
+		return nil
+	}
+	// ParseFloat quietly rounds numbers too close to zero down to 0
+	if value == 0 && strings.ContainsAny(strings.SplitN(strings.ToLower(p.curToken.Literal), "e", 2)[0], "123456789") {
+		p.errorAt(diagnostic.NumberOutOfRange, p.curToken, "%s is too small for a 64 bit float 🤏", p.curToken.Literal)
 		return nil
 	}
 
@@ -485,10 +717,59 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 	return lit
 }
 
+// parseIllegal skips over a token the lexer could not make sense of. The
+// lexer has already reported why, so the statement is just marked as broken.
+func (p *Parser) parseIllegal() ast.Expression {
+	p.failed = true
+	return nil
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+	str.Parts = appendStringPart(str.Parts, p.curToken)
+
+	for {
+		p.nextToken()
+
+		if p.curTokenIs(token.TEMPLATE_MIDDLE) || p.curTokenIs(token.TEMPLATE_TAIL) {
+			p.errorAt(diagnostic.EmptyInterpolation, p.curToken, "there's nothing inside this ${} - put an expression in there 🫙")
+			return nil
+		}
+
+		part := p.parseExpression(LOWEST)
+		if part == nil {
+			return nil
+		}
+		str.Parts = append(str.Parts, part)
+
+		if !p.peekTokenIs(token.TEMPLATE_MIDDLE) && !p.peekTokenIs(token.TEMPLATE_TAIL) {
+			p.errorAt(diagnostic.UnterminatedInterpolation, p.peekToken, "you opened a ${ in a string but never closed it with a } 🧵")
+			return nil
+		}
+
+		p.nextToken()
+		str.Parts = appendStringPart(str.Parts, p.curToken)
+
+		if p.curTokenIs(token.TEMPLATE_TAIL) {
+			str.Tail = p.curToken
+			return str
+		}
+	}
+}
+
+// appendStringPart adds the text of a template token to the parts of an
+// interpolated string, skipping empty pieces
+func appendStringPart(parts []ast.Expression, tok token.Token) []ast.Expression {
+	if tok.Literal == "" {
+		return parts
+	}
+	return append(parts, &ast.StringLiteral{Token: tok, Value: tok.Literal})
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
@@ -510,12 +791,31 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	}
 
 	precedence := p.curPrecedence()
+	if p.curTokenIs(token.POWER) {
+		// ** is right associative: 2 ** 3 ** 2 is 2 ** (3 ** 2)
+		precedence -= 1
+	}
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 
 	return expression
 }
 
+func (p *Parser) parseRangeExpression(start ast.Expression) ast.Expression {
+	expression := &ast.RangeExpression{Token: p.curToken, Start: start}
+
+	p.nextToken()
+	expression.End = p.parseExpression(RANGE)
+
+	if p.peekTokenIs(token.BY) {
+		p.nextToken()
+		p.nextToken()
+		expression.Step = p.parseExpression(RANGE)
+	}
+
+	return expression
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
 }
@@ -599,16 +899,13 @@ func (p *Parser) parseIfExpression() ast.Expression {
 
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
-	block.Statements = []ast.Statement{}
 
 	p.nextToken()
 
-	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
-		}
-		p.nextToken()
+	block.Statements = p.parseStatements(token.RBRACE)
+
+	if p.curTokenIs(token.RBRACE) {
+		block.Rbrace = p.curToken
 	}
 
 	return block
@@ -632,39 +929,299 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return lit
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+func (p *Parser) parseFunctionParameters() []*ast.Parameter {
+	params := []*ast.Parameter{}
 
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return identifiers
+		return params
 	}
 
-	p.nextToken()
+	seen := map[string]bool{}
+	for {
+		param := p.parseFunctionParameter()
+		if param == nil {
+			return nil
+		}
 
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
+		var duplicate *ast.Identifier
+		for _, n := range ast.PatternNames(param.Name) {
+			if seen[n.Value] && duplicate == nil {
+				duplicate = n
+			}
+			seen[n.Value] = true
+		}
 
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
+		name := patternToken(param.Name)
+		switch {
+		case duplicate != nil:
+			p.errorAt(diagnostic.InvalidParameter, duplicate.Token, "%s is already a parameter - every parameter needs its own name 👯", duplicate.Value)
+		case len(params) > 0 && params[len(params)-1].Rest:
+			p.errorAt(diagnostic.InvalidParameter, name, "%s has to be the last parameter, it gets whatever arguments are left over 🧺", params[len(params)-1])
+		case param.Rest && param.Default != nil:
+			p.errorAt(diagnostic.InvalidParameter, name, "%s can't have a default, it's just an empty array when nothing is left over 🧺", param)
+		case !param.Rest && param.Default == nil && len(params) > 0 && params[len(params)-1].Default != nil:
+			p.errorAt(diagnostic.InvalidParameter, name, "%s needs a default too, since the parameter before it has one 🤓", param.Name)
+		}
+
+		params = append(params, param)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
 		p.nextToken()
-		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		identifiers = append(identifiers, ident)
 	}
 
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
 
-	return identifiers
+	return params
+}
+
+// parseFunctionParameter parses name, name = default or ...name, starting
+// with the token before it as the current token. The name can also be an
+// [a, b] or {a, b} pattern, but not for ...name.
+func (p *Parser) parseFunctionParameter() *ast.Parameter {
+	param := &ast.Parameter{}
+
+	if p.peekTokenIs(token.ELLIPSIS) {
+		p.nextToken()
+		param.Rest = true
+
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		param.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	} else {
+		param.Name = p.parsePattern()
+		if param.Name == nil {
+			return nil
+		}
+	}
+
+	if p.peekTokenIs(token.ASSIGN) {
+		p.nextToken()
+		p.nextToken()
+		param.Default = p.parseExpression(LOWEST)
+	}
+
+	return param
+}
+
+// parsePattern parses what a value gets bound to, starting with the token
+// before it as the current token: a name, [a, b = 2, ...rest] or
+// {name, age: years, ...rest}
+func (p *Parser) parsePattern() ast.Pattern {
+	switch {
+	case p.peekTokenIs(token.LBRACKET):
+		p.nextToken()
+		return p.parseArrayPattern()
+	case p.peekTokenIs(token.LBRACE):
+		p.nextToken()
+		return p.parseHashPattern()
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACKET) {
+		el := p.parseFunctionParameter()
+		if el == nil {
+			return nil
+		}
+
+		switch {
+		case len(pattern.Elements) > 0 && pattern.Elements[len(pattern.Elements)-1].Rest:
+			p.errorAt(diagnostic.InvalidPattern, patternToken(el.Name), "%s has to come last, it gets whatever items are left over 🧺", pattern.Elements[len(pattern.Elements)-1])
+		case el.Rest && el.Default != nil:
+			p.errorAt(diagnostic.InvalidPattern, patternToken(el.Name), "%s can't have a default, it's just an empty array when nothing is left over 🧺", el)
+		}
+
+		pattern.Elements = append(pattern.Elements, el)
+
+		if !p.peekTokenIs(token.RBRACKET) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	pattern.Rbracket = p.curToken
+
+	return pattern
+}
+
+func (p *Parser) parseHashPattern() ast.Pattern {
+	pattern := &ast.HashPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		pair := &ast.HashPatternPair{}
+
+		if p.peekTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			pair.Value = &ast.Parameter{Name: name, Rest: true}
+		} else {
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			pair.Key = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			pair.Value = &ast.Parameter{Name: pair.Key}
+
+			// {key: pattern} binds the value somewhere other than key
+			if p.peekTokenIs(token.COLON) {
+				p.nextToken()
+				pair.Value.Name = p.parsePattern()
+				if pair.Value.Name == nil {
+					return nil
+				}
+			}
+
+			if p.peekTokenIs(token.ASSIGN) {
+				p.nextToken()
+				p.nextToken()
+				pair.Value.Default = p.parseExpression(LOWEST)
+			}
+		}
+
+		if n := len(pattern.Pairs); n > 0 && pattern.Pairs[n-1].Value.Rest {
+			p.errorAt(diagnostic.InvalidPattern, patternToken(pair.Value.Name), "%s has to come last, it gets whatever keys are left over 🧺", pattern.Pairs[n-1].Value)
+		}
+
+		pattern.Pairs = append(pattern.Pairs, pair)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	pattern.Rbrace = p.curToken
+
+	return pattern
+}
+
+// checkPatternNames reports a name that shows up twice in the patterns of
+// one fr or stalk, like fr [a, a] = pair
+func (p *Parser) checkPatternNames(patterns ...ast.Pattern) {
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		for _, name := range ast.PatternNames(pattern) {
+			if seen[name.Value] {
+				p.errorAt(diagnostic.InvalidPattern, name.Token, "%s shows up twice here - every name needs its own spot 👯", name.Value)
+				return
+			}
+			seen[name.Value] = true
+		}
+	}
+}
+
+// patternToken is the first token of a pattern, for pointing errors at it
+func patternToken(pattern ast.Pattern) token.Token {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		return pattern.Token
+	case *ast.ArrayPattern:
+		return pattern.Token
+	case *ast.HashPattern:
+		return pattern.Token
+	}
+	return token.Token{}
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	exp.Arguments = p.parseCallArguments()
+	exp.Rparen = p.curToken
 	return exp
 }
 
+// parseCallArguments parses the arguments of a call like f(a, b, name: c),
+// where any named arguments come after the positional ones
+func (p *Parser) parseCallArguments() []ast.Expression {
+	args := []ast.Expression{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return args
+	}
+
+	named := false
+	for {
+		p.nextToken()
+
+		if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COLON) {
+			arg := &ast.NamedArgument{
+				Token: p.curToken,
+				Name:  &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+			}
+			p.nextToken()
+			p.nextToken()
+			arg.Value = p.parseExpression(LOWEST)
+			args = append(args, arg)
+			named = true
+		} else {
+			if named {
+				p.errorAt(diagnostic.InvalidArgumentOrder, p.curToken, "positional arguments have to come before the named ones 🤓")
+			}
+			args = append(args, p.parseListElement())
+		}
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return args
+}
+
+// parsePipeExpression lowers x |> f into f(x) and x |> f(a, b) into
+// f(x, a, b), so the evaluator only ever sees regular calls
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	pipe := p.curToken
+	precedence := p.curPrecedence()
+
+	p.nextToken()
+	right := p.parseExpression(precedence)
+	if right == nil {
+		return nil
+	}
+
+	if call, ok := right.(*ast.CallExpression); ok && !call.Piped {
+		call.Arguments = append([]ast.Expression{left}, call.Arguments...)
+		call.Piped = true
+		return call
+	}
+
+	return &ast.CallExpression{
+		Token:     pipe,
+		Function:  right,
+		Arguments: []ast.Expression{left},
+		Piped:     true,
+	}
+}
+
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	list := []ast.Expression{}
 
@@ -674,12 +1231,12 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	}
 
 	p.nextToken()
-	list = append(list, p.parseExpression(LOWEST))
+	list = append(list, p.parseListElement())
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		list = append(list, p.parseExpression(LOWEST))
+		list = append(list, p.parseListElement())
 	}
 
 	if !p.expectPeek(end) {
@@ -689,33 +1246,107 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	return list
 }
 
+// parseListElement parses an element of an array literal or an argument
+// of a call, either of which can be spread with ...
+func (p *Parser) parseListElement() ast.Expression {
+	if p.curTokenIs(token.ELLIPSIS) {
+		return p.parseSpreadExpression()
+	}
+	return p.parseExpression(LOWEST)
+}
+
+func (p *Parser) parseSpreadExpression() *ast.SpreadExpression {
+	exp := &ast.SpreadExpression{Token: p.curToken}
+	p.nextToken()
+	exp.Value = p.parseExpression(LOWEST)
+	return exp
+}
+
+// parseMisplacedSpread reports a ... anywhere it can't be used, still
+// parsing what follows so the rest of the statement makes sense
+func (p *Parser) parseMisplacedSpread() ast.Expression {
+	p.errorAt(diagnostic.MisplacedSpread, p.curToken, "... only works inside [], {} or the () of a call - there's nowhere to spread it here 🧈")
+	return p.parseSpreadExpression()
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 
 	array.Elements = p.parseExpressionList(token.RBRACKET)
+	array.Rbracket = p.curToken
 
 	return array
 }
 
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	exp := &ast.IndexExpression{
+		Token:    p.curToken,
+		Left:     left,
+		Optional: p.curTokenIs(token.OPTIONAL_LBRACKET),
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		return p.parseSliceExpression(exp)
+	}
 
 	p.nextToken()
 	exp.Index = p.parseExpression(LOWEST)
 
+	if p.peekTokenIs(token.COLON) {
+		return p.parseSliceExpression(exp)
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	exp.Rbracket = p.curToken
+
+	return exp
+}
+
+// parseSliceExpression parses the rest of a[start:end] once the peek token
+// is the colon, taking whatever was already parsed as the start
+func (p *Parser) parseSliceExpression(index *ast.IndexExpression) ast.Expression {
+	exp := &ast.SliceExpression{
+		Token:    index.Token,
+		Left:     index.Left,
+		Start:    index.Index,
+		Optional: index.Optional,
+	}
+
+	p.nextToken()
+
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		exp.End = p.parseExpression(LOWEST)
+	}
+
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
 
+	exp.Rbracket = p.curToken
+
 	return exp
 }
 
 func (p *Parser) parseHashLiteral() ast.Expression {
 	hash := &ast.HashLiteral{Token: p.curToken}
-	hash.Pairs = make(map[ast.Expression]ast.Expression)
+	hash.Pairs = []ast.HashPair{}
 
 	for !p.peekTokenIs(token.RBRACE) {
 		p.nextToken()
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			hash.Pairs = append(hash.Pairs, ast.HashPair{Spread: p.parseSpreadExpression()})
+
+			if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+				return nil
+			}
+			continue
+		}
+
 		key := p.parseExpression(LOWEST)
 
 		if !p.expectPeek(token.COLON) {
@@ -725,7 +1356,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 
-		hash.Pairs[key] = value
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
 
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
@@ -736,6 +1367,8 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		return nil
 	}
 
+	hash.Rbrace = p.curToken
+
 	return hash
 }
 
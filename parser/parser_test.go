@@ -3,8 +3,11 @@ package parser
 import (
 	"fmt"
 	"nocap/ast"
+	"nocap/diagnostic"
 	"nocap/lexer"
+	"nocap/token"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -42,6 +45,58 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"deadass x = 5;", "deadass x = 5;"},
+		{"deadass add = cook(a, b) { a + b };", "deadass add = cook(a, b) (a + b);"},
+		{"deadass cook add(a, b) { a + b }", "deadass cook add(a, b) (a + b)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d",
+				len(program.Statements))
+		}
+
+		switch stmt := program.Statements[0].(type) {
+		case *ast.LetStatement:
+			if !stmt.Constant {
+				t.Errorf("%q: stmt.Constant is false", tt.input)
+			}
+		case *ast.FunctionStatement:
+			if !stmt.Constant {
+				t.Errorf("%q: stmt.Constant is false", tt.input)
+			}
+		default:
+			t.Fatalf("%q: wrong statement. got=%T", tt.input, stmt)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong output. expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	l := lexer.New("deadass = 5; fr y = 1")
+	p := New(l)
+	program := p.ParseProgram()
+
+	expected := "1:9: bruh I needed a identifier, why did you hit me with a = instead 🤦‍♀️"
+	if len(p.Errors()) != 1 || p.Errors()[0] != expected {
+		t.Errorf("wrong errors. expected=%q, got=%q", expected, p.Errors())
+	}
+	if len(program.Statements) != 1 || !testLetStatement(t, program.Statements[0], "y") {
+		t.Errorf("didn't recover after the bad deadass. got=%q", program.String())
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input         string
@@ -105,12 +160,12 @@ func TestFunctionStatement(t *testing.T) {
 			len(stmt.Parameters))
 	}
 
-	if stmt.Parameters[0].Value != "x" {
-		t.Fatalf("stmt.Parameters[0].Value not '%s'. got=%s", "x", stmt.Parameters[0].Value)
+	if stmt.Parameters[0].Name.String() != "x" {
+		t.Fatalf("stmt.Parameters[0].Name.String() not '%s'. got=%s", "x", stmt.Parameters[0].Name.String())
 	}
 
-	if stmt.Parameters[1].Value != "y" {
-		t.Fatalf("stmt.Parameters[1].Value not '%s'. got=%s", "y", stmt.Parameters[1].Value)
+	if stmt.Parameters[1].Name.String() != "y" {
+		t.Fatalf("stmt.Parameters[1].Name.String() not '%s'. got=%s", "y", stmt.Parameters[1].Name.String())
 	}
 
 	if len(stmt.Body.Statements) != 1 {
@@ -149,8 +204,8 @@ func TestForStatement(t *testing.T) {
 			program.Statements[0])
 	}
 
-	if stmt.Key.Value != "x" {
-		t.Fatalf("stmt.Key.Value not '%s'. got=%s", "x", stmt.Key.Value)
+	if stmt.Key.String() != "x" {
+		t.Fatalf("stmt.Key.String() not '%s'. got=%s", "x", stmt.Key.String())
 	}
 
 	if !testIdentifier(t, stmt.Items, "y") {
@@ -173,6 +228,42 @@ func TestForStatement(t *testing.T) {
 	}
 }
 
+func TestForStatementWithKeyAndValue(t *testing.T) {
+	input := `stalk (k, v in h) { v; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ForStatment. got=%T",
+			program.Statements[0])
+	}
+
+	if stmt.Key.String() != "k" {
+		t.Fatalf("stmt.Key.String() not '%s'. got=%s", "k", stmt.Key.String())
+	}
+
+	if stmt.Value == nil || stmt.Value.String() != "v" {
+		t.Fatalf("stmt.Value not '%s'. got=%v", "v", stmt.Value)
+	}
+
+	if stmt.String() != "stalk(k, v in h) v" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+
+	l = lexer.New(`stalk (k, in h) { v; }`)
+	p = New(l)
+	p.ParseProgram()
+
+	expected := "1:11: bruh I needed a identifier, why did you hit me with a in instead 🤦‍♀️"
+	if len(p.Errors()) == 0 || p.Errors()[0] != expected {
+		t.Errorf("wrong errors. expected=%q, got=%q", expected, p.Errors())
+	}
+}
+
 func TestWhileStatement(t *testing.T) {
 	input := `onRepeat (x < y) { 
 		x;
@@ -281,6 +372,51 @@ func TestAssignmentStatement(t *testing.T) {
 	}
 }
 
+func TestCompoundAssignmentStatements(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedOperator string
+		expectedString   string
+	}{
+		{"count += 1;", "+", "count += 1;"},
+		{"count -= x * 2;", "-", "count -= (x * 2);"},
+		{"count *= 3", "*", "count *= 3;"},
+		{"count /= 4", "/", "count /= 4;"},
+		{"count %= 5", "%", "count %= 5;"},
+		{"arr[1] += 1;", "+", "(arr[1]) += 1;"},
+		{"h[\"a\"][i] -= 2", "-", "((h[a])[i]) -= 2;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		var operator string
+		switch stmt := program.Statements[0].(type) {
+		case *ast.AssignmentStatement:
+			operator = stmt.Operator
+		case *ast.IndexExpressionAssignmentStatement:
+			operator = stmt.Operator
+		default:
+			t.Fatalf("stmt is not an assignment. got=%T", program.Statements[0])
+		}
+
+		if operator != tt.expectedOperator {
+			t.Errorf("operator wrong. expected=%q, got=%q", tt.expectedOperator, operator)
+		}
+
+		if program.String() != tt.expectedString {
+			t.Errorf("expected=%q, got=%q", tt.expectedString, program.String())
+		}
+	}
+}
+
 func TestIdentifierExpression(t *testing.T) {
 	input := "foobar;"
 
@@ -374,6 +510,81 @@ func TestFloatLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestNumberLiteralForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"0xFF", 255},
+		{"0Xff", 255},
+		{"0b1010", 10},
+		{"0o17", 15},
+		{"1_000_000", 1000000},
+		{"0x_FF_FF", 65535},
+		{"010", 10},
+		{"6.02e23", 6.02e23},
+		{"1E-5", 1e-5},
+		{"1_000.5", 1000.5},
+		{"9223372036854775807", 9223372036854775807},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		switch expected := tt.expected.(type) {
+		case int:
+			literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+			if !ok {
+				t.Fatalf("exp not *ast.IntegerLiteral. got=%T", stmt.Expression)
+			}
+			if literal.Value != int64(expected) {
+				t.Errorf("literal.Value not %d. got=%d", expected, literal.Value)
+			}
+		case float64:
+			literal, ok := stmt.Expression.(*ast.FloatLiteral)
+			if !ok {
+				t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+			}
+			if literal.Value != expected {
+				t.Errorf("literal.Value not %g. got=%g", expected, literal.Value)
+			}
+		}
+	}
+}
+
+func TestNumberLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775808", "1:1: 9223372036854775808 is too big for a 64 bit integer, the max is 9223372036854775807 🤯"},
+		{"fr x = 0xFFFF_FFFF_FFFF_FFFF_F;", "1:8: 0xFFFF_FFFF_FFFF_FFFF_F is too big for a 64 bit integer, the max is 9223372036854775807 🤯"},
+		{"1e400", "1:1: 1e400 is too big for a 64 bit float 🤯"},
+		{"1e-400", "1:1: 1e-400 is too small for a 64 bit float 🤏"},
+		{"0.000_001e-330", "1:1: 0.000_001e-330 is too small for a 64 bit float 🤏"},
+		{"fr x = 1.2.3;", "1:11: 1.2.3 is not a valid number: a number can only have one decimal point 🔢"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("expected 1 error for %q, got %d: %v", tt.input, len(errors), errors)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input    string
@@ -615,6 +826,98 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"nah x and y or z",
 			"(((nah x) and y) or z)",
 		},
+		{
+			"2 ** 3 ** 2",
+			"(2 ** (3 ** 2))",
+		},
+		{
+			"-2 ** 2",
+			"(-(2 ** 2))",
+		},
+		{
+			"a * b ** c + d",
+			"((a * (b ** c)) + d)",
+		},
+		{
+			"2 ** -x",
+			"(2 ** (-x))",
+		},
+		{
+			"a ** b[1]",
+			"(a ** (b[1]))",
+		},
+		{
+			"a + b ~/ c * d",
+			"(a + ((b ~/ c) * d))",
+		},
+		{
+			"a | b ^ c & d",
+			"(a | (b ^ (c & d)))",
+		},
+		{
+			"a & b << 2 + c",
+			"(a & (b << (2 + c)))",
+		},
+		{
+			"a >> 1 << 2",
+			"((a >> 1) << 2)",
+		},
+		{
+			"flags & mask is 0",
+			"((flags & mask) is 0)",
+		},
+		{
+			"a < b | c",
+			"(a < (b | c))",
+		},
+		{
+			"~a & -b",
+			"((~a) & (-b))",
+		},
+		{
+			"a ?? b or c",
+			"(a ?? (b or c))",
+		},
+		{
+			"a ?? b ?? c",
+			"((a ?? b) ?? c)",
+		},
+		{
+			"config?[\"db\"]?[\"port\"] ?? 5432 + 1",
+			"(((config?[db])?[port]) ?? (5432 + 1))",
+		},
+		{
+			"a?[1][2]",
+			"((a?[1])[2])",
+		},
+		{
+			"x |> f",
+			"(x |> f)",
+		},
+		{
+			"a + b |> f |> g(2) * 3",
+			"(((a + b) |> f) |> (g(2) * 3))",
+		},
+		{
+			"x |> f ?? g",
+			"(x |> (f ?? g))",
+		},
+		{
+			"1..n + 1",
+			"(1..(n + 1))",
+		},
+		{
+			"10..1 by -2 * k",
+			"(10..1 by ((-2) * k))",
+		},
+		{
+			"a..b is c",
+			"((a..b) is c)",
+		},
+		{
+			"(1..10)[3]",
+			"((1..10)[3])",
+		},
 	}
 
 	for _, tt := range tests {
@@ -833,8 +1136,8 @@ func TestFunctionLiteralParsing(t *testing.T) {
 			len(function.Parameters))
 	}
 
-	testLiteralExpression(t, function.Parameters[0], "x")
-	testLiteralExpression(t, function.Parameters[1], "y")
+	testLiteralExpression(t, function.Parameters[0].Name.(*ast.Identifier), "x")
+	testLiteralExpression(t, function.Parameters[1].Name.(*ast.Identifier), "y")
 
 	if len(function.Body.Statements) != 1 {
 		t.Fatalf("function.Body.Statements has not 1 statements. got=%d\n",
@@ -869,13 +1172,228 @@ func TestFunctionParameterParsing(t *testing.T) {
 		stmt := program.Statements[0].(*ast.ExpressionStatement)
 		function := stmt.Expression.(*ast.FunctionLiteral)
 
-		if len(function.Parameters) != len(tt.expectedParams) {
-			t.Errorf("length parameters wrong. want %d, got=%d\n",
-				len(tt.expectedParams), len(function.Parameters))
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Errorf("length parameters wrong. want %d, got=%d\n",
+				len(tt.expectedParams), len(function.Parameters))
+		}
+
+		for i, ident := range tt.expectedParams {
+			testLiteralExpression(t, function.Parameters[i].Name.(*ast.Identifier), ident)
+		}
+	}
+}
+
+func TestDefaultAndRestParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`cook(name, greeting = "yo") { name }`, "cook(name, greeting = yo) name"},
+		{`cook(a, b = a * 2, ...rest) { rest }`, "cook(a, b = (a * 2), ...rest) rest"},
+		{`cook(...all) { all }`, "cook(...all) all"},
+		{`cook greet(name = "bestie") { name }`, "cook greet(name = bestie) name"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestNamedArguments(t *testing.T) {
+	l := lexer.New(`greet("bestie", greeting: "sup", times: 1 + 1)`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.CallExpression. got=%T", stmt.Expression)
+	}
+
+	if len(call.Arguments) != 3 {
+		t.Fatalf("wrong length of arguments. got=%d", len(call.Arguments))
+	}
+
+	if str, ok := call.Arguments[0].(*ast.StringLiteral); !ok || str.Value != "bestie" {
+		t.Errorf("first argument is not \"bestie\". got=%T (%+v)", call.Arguments[0], call.Arguments[0])
+	}
+
+	named, ok := call.Arguments[2].(*ast.NamedArgument)
+	if !ok {
+		t.Fatalf("argument is not ast.NamedArgument. got=%T", call.Arguments[2])
+	}
+	if named.Name.Value != "times" {
+		t.Errorf("wrong name. got=%q", named.Name.Value)
+	}
+	testInfixExpression(t, named.Value, 1, "+", 1)
+
+	if call.String() != "greet(bestie, greeting: sup, times: (1 + 1))" {
+		t.Errorf("wrong output. got=%q", call.String())
+	}
+}
+
+func TestParameterAndArgumentErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"cook(a, a) { a }", "1:9: a is already a parameter - every parameter needs its own name 👯"},
+		{"cook(...rest, a) { a }", "1:15: ...rest has to be the last parameter, it gets whatever arguments are left over 🧺"},
+		{"cook(...rest = [1]) { rest }", "1:9: ...rest can't have a default, it's just an empty array when nothing is left over 🧺"},
+		{"cook(a = 1, b) { b }", "1:13: b needs a default too, since the parameter before it has one 🤓"},
+		{"cook(a, 1) { a }", "1:9: bruh I needed a identifier, why did you hit me with a integer instead 🤦‍♀️"},
+		{"cook(a, [b, a]) { a }", "1:13: a is already a parameter - every parameter needs its own name 👯"},
+		{"f(a: 1, 2)", "1:9: positional arguments have to come before the named ones 🤓"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q, got none", tt.input)
+			continue
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestSpreadExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[...a, 1]", "[...a, 1]"},
+		{"[0, ...a + b]", "[0, ...(a + b)]"},
+		{"f(1, ...rest)", "f(1, ...rest)"},
+		{"f(...a, times: 2)", "f(...a, times: 2)"},
+		{`{...h, "k": 1}`, `{...h, k:1}`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.String(); got != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	l := lexer.New("fr x = ...a")
+	p := New(l)
+	p.ParseProgram()
+
+	expected := "1:8: ... only works inside [], {} or the () of a call - there's nowhere to spread it here 🧈"
+	if errors := p.Errors(); len(errors) != 1 || errors[0] != expected {
+		t.Errorf("wrong errors. expected=%q, got=%q", expected, errors)
+	}
+}
+
+func TestDestructuringPatterns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fr [a, b] = pair", "fr [a, b] = pair;"},
+		{"fr [first, second = 2, ...rest] = arr", "fr [first, second = 2, ...rest] = arr;"},
+		{"fr [] = arr", "fr [] = arr;"},
+		{"fr {name, age} = person", "fr {name, age} = person;"},
+		{`fr {name: n, age = 18, ...others} = person`, "fr {name: n, age = 18, ...others} = person;"},
+		{"fr {address: {city}, tags: [top]} = person", "fr {address: {city}, tags: [top]} = person;"},
+		{"fr [[a, b], {c}] = nested", "fr [[a, b], {c}] = nested;"},
+		{"deadass [a, b] = pair", "deadass [a, b] = pair;"},
+		{"stalk ([a, b] in pairs) { a }", "stalk([a, b] in pairs) a"},
+		{"stalk (i, {name} in people) { name }", "stalk(i, {name} in people) name"},
+		{"cook([x, y], {name} = {}) { x }", "cook([x, y], {name} = {}) x"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.String(); got != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	l := lexer.New("fr {address: {city}, ...rest} = person")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.LetStatement)
+	pattern, ok := stmt.Name.(*ast.HashPattern)
+	if !ok {
+		t.Fatalf("stmt.Name is not ast.HashPattern. got=%T", stmt.Name)
+	}
+	if len(pattern.Pairs) != 2 {
+		t.Fatalf("wrong number of pairs. got=%d", len(pattern.Pairs))
+	}
+	if pattern.Pairs[0].Key.Value != "address" {
+		t.Errorf("wrong key. got=%q", pattern.Pairs[0].Key.Value)
+	}
+	if _, ok := pattern.Pairs[0].Value.Name.(*ast.HashPattern); !ok {
+		t.Errorf("nested pattern is not ast.HashPattern. got=%T", pattern.Pairs[0].Value.Name)
+	}
+	if pattern.Pairs[1].Key != nil || !pattern.Pairs[1].Value.Rest {
+		t.Errorf("last pair is not ...rest. got=%+v", pattern.Pairs[1])
+	}
+
+	names := []string{}
+	for _, name := range ast.PatternNames(stmt.Name) {
+		names = append(names, name.Value)
+	}
+	if strings.Join(names, ", ") != "city, rest" {
+		t.Errorf("wrong names. got=%q", names)
+	}
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fr [a, a] = pair", "1:8: a shows up twice here - every name needs its own spot 👯"},
+		{"fr {a, b: [a]} = h", "1:12: a shows up twice here - every name needs its own spot 👯"},
+		{"stalk (k, [k, v] in h) { k }", "1:12: k shows up twice here - every name needs its own spot 👯"},
+		{"fr [...rest, a] = arr", "1:14: ...rest has to come last, it gets whatever items are left over 🧺"},
+		{"fr [...rest = []] = arr", "1:8: ...rest can't have a default, it's just an empty array when nothing is left over 🧺"},
+		{"fr {...rest, a} = h", "1:14: ...rest has to come last, it gets whatever keys are left over 🧺"},
+		{"fr [...[a]] = arr", "1:8: bruh I needed a identifier, why did you hit me with a [ instead 🤦‍♀️"},
+		{`fr {"a"} = h`, "1:5: bruh I needed a identifier, why did you hit me with a string instead 🤦‍♀️"},
+		{"fr [a, b = pair", "1:16: bruh I needed a ,, why did you hit me with a end of file instead 🤦‍♀️"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q, got none", tt.input)
+			continue
 		}
 
-		for i, ident := range tt.expectedParams {
-			testLiteralExpression(t, function.Parameters[i], ident)
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, errors[0])
 		}
 	}
 }
@@ -991,6 +1509,89 @@ func TestStringLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestInterpolatedStringParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		parts    int
+	}{
+		{`"yo ${name}!"`, "yo ${name}!", 3},
+		{`"${a + b}"`, "${(a + b)}", 1},
+		{`"you got ${count(items)} items, ${"nested ${x * 2}"}"`, "you got ${count(items)} items, ${nested ${(x * 2)}}", 4},
+		{`"cost: \${price} is ${price}"`, "cost: \\${price} is ${price}", 2},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		str, ok := stmt.Expression.(*ast.InterpolatedString)
+		if !ok {
+			t.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+		}
+
+		if str.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, str.String())
+		}
+
+		if len(str.Parts) != tt.parts {
+			t.Errorf("wrong number of parts. expected=%d, got=%d", tt.parts, len(str.Parts))
+		}
+	}
+}
+
+func TestInterpolatedStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"empty ${}"`, "1:10: there's nothing inside this ${} - put an expression in there 🫙"},
+		{`"open ${name`, "1:13: you opened a ${ in a string but never closed it with a } 🧵"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestLexerErrorsAreReported(t *testing.T) {
+	input := "fr greeting = \"yo\\q\";\nfr oops = \"never ends"
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	expected := []string{
+		`1:18: idk what \q is supposed to mean - try \n, \t, \", \\ or \u{...} 🧐`,
+		"2:11: this string never ends - you forgot the closing \" 🧵",
+	}
+
+	errors := p.Errors()
+	if len(errors) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errors), errors)
+	}
+
+	for i, msg := range expected {
+		if errors[i] != msg {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, msg, errors[i])
+		}
+	}
+}
+
 func TestParsingEmptyArrayLiterals(t *testing.T) {
 	input := "[]"
 
@@ -1056,6 +1657,66 @@ func TestParsingIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestOptionalIndexAssignmentError(t *testing.T) {
+	input := "a?[1] = 2"
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	expected := []string{
+		"1:2: you can't assign through ?[ - there might be nothing there to assign to 👻",
+	}
+
+	errors := p.Errors()
+	if len(errors) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errors), errors)
+	}
+
+	for i, msg := range expected {
+		if errors[i] != msg {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, msg, errors[i])
+		}
+	}
+}
+
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a[2:4]", "(a[2:4])"},
+		{"a[:4]", "(a[:4])"},
+		{"a[2:]", "(a[2:])"},
+		{"a[:]", "(a[:])"},
+		{"a[-2:-1]", "(a[(-2):(-1)])"},
+		{"a[1 + 1:n * 2]", "(a[(1 + 1):(n * 2)])"},
+		{"a?[1:2]", "(a?[1:2])"},
+		{"a[1:2][1]", "((a[1:2])[1])"},
+		{`{"k": a[1:2]}["k"]`, "({k:(a[1:2])}[k])"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+
+	l := lexer.New("a[1:2] = [3]")
+	p := New(l)
+	p.ParseProgram()
+
+	expected := "1:8: you can't assign to a slice - change the items one at a time instead ✂️"
+	if len(p.Errors()) != 1 || p.Errors()[0] != expected {
+		t.Errorf("wrong errors. expected=%q, got=%q", expected, p.Errors())
+	}
+}
+
 func TestParsingEmptyHashLiteral(t *testing.T) {
 	input := "{}"
 
@@ -1099,7 +1760,8 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		literal, ok := key.(*ast.StringLiteral)
 		if !ok {
 			t.Errorf("key is not ast.StringLiteral. got=%T", key)
@@ -1134,7 +1796,8 @@ func TestParsingHashLiteralsBooleanKeys(t *testing.T) {
 		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		boolean, ok := key.(*ast.Boolean)
 		if !ok {
 			t.Errorf("key is not ast.BooleanLiteral. got=%T", key)
@@ -1170,7 +1833,8 @@ func TestParsingHashLiteralsIntegerKeys(t *testing.T) {
 		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		integer, ok := key.(*ast.IntegerLiteral)
 		if !ok {
 			t.Errorf("key is not ast.IntegerLiteral. got=%T", key)
@@ -1213,7 +1877,8 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 		},
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		literal, ok := key.(*ast.StringLiteral)
 		if !ok {
 			t.Errorf("key is not ast.StringLiteral. got=%T", key)
@@ -1230,6 +1895,36 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 	}
 }
 
+func TestParsingHashLiteralsKeepOrder(t *testing.T) {
+	input := `{"z": 1, "a": 2, 3: 3, "m": 4, "a": 5}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	expectedKeys := []string{"z", "a", "3", "m", "a"}
+	if len(hash.Pairs) != len(expectedKeys) {
+		t.Fatalf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+
+	for i, key := range expectedKeys {
+		if hash.Pairs[i].Key.String() != key {
+			t.Errorf("hash.Pairs[%d] has wrong key. expected=%q, got=%q", i, key, hash.Pairs[i].Key.String())
+		}
+	}
+
+	if hash.String() != "{z:1, a:2, 3:3, m:4, a:5}" {
+		t.Errorf("hash.String() wrong. got=%q", hash.String())
+	}
+}
+
 func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
 	if s.TokenLiteral() != "fr" {
 		t.Errorf("s.TokenLiteral not 'fr'. got=%q", s.TokenLiteral())
@@ -1242,8 +1937,8 @@ func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
 		return false
 	}
 
-	if letStmt.Name.Value != name {
-		t.Errorf("letStmt.Name.Value not '%s'. got=%s", name, letStmt.Name.Value)
+	if letStmt.Name.String() != name {
+		t.Errorf("letStmt.Name.String() not '%s'. got=%s", name, letStmt.Name.String())
 		return false
 	}
 
@@ -2066,3 +2761,331 @@ func TestComplexElseIfConditions(t *testing.T) {
 		return
 	}
 }
+
+func TestParserErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"fr x 5;",
+			"1:6: bruh I needed a =, why did you hit me with a integer instead 🤦‍♀️",
+		},
+		{
+			"fr x = 1;\nfr y = );",
+			"2:8: you can't lead with a ) - that's not how you begin things! 🤷‍♀️",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestNodeSpans(t *testing.T) {
+	input := `fr add = cook(a, b) {
+  yeet a + b;
+};
+add(1, [2, 3][1])`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{program, "1:1-4:18"},
+		{program.Statements[0], "1:1-3:2"},
+		{program.Statements[0].(*ast.LetStatement).Value, "1:10-3:2"},
+		{program.Statements[1], "4:1-4:18"},
+		{program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression).Arguments[1], "4:8-4:17"},
+	}
+
+	for i, tt := range tests {
+		span := tt.node.Span()
+		got := fmt.Sprintf("%d:%d-%d:%d", span.Start.Line, span.Start.Column, span.End.Line, span.End.Column)
+		if got != tt.expected {
+			t.Errorf("tests[%d] - span wrong. expected=%s, got=%s", i, tt.expected, got)
+		}
+	}
+}
+
+func TestPipeExpressions(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedCall  string
+		expectedArgs  []string
+		expectedSpan  string
+		expectedPrint string
+	}{
+		{"x |> h", "h", []string{"x"}, "1:1-1:7", "(x |> h)"},
+		{"x |> h()", "h", []string{"x"}, "1:1-1:9", "(x |> h())"},
+		{"x |> g(2, 3)", "g", []string{"x", "2", "3"}, "1:1-1:13", "(x |> g(2, 3))"},
+		{"x |> h |> g(2)", "g", []string{"(x |> h)", "2"}, "1:1-1:15", "((x |> h) |> g(2))"},
+		{"x |> make(1)(2)", "make(1)", []string{"x", "2"}, "1:1-1:16", "(x |> make(1)(2))"},
+		{"x |> (y |> f)", "(y |> f)", []string{"x"}, "1:1-1:13", "(x |> (y |> f))"},
+		{"x |> cook(n) { n }", "cook(n) n", []string{"x"}, "1:1-1:19", "(x |> cook(n) n)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		call, ok := stmt.Expression.(*ast.CallExpression)
+		if !ok {
+			t.Fatalf("%q: exp not *ast.CallExpression. got=%T", tt.input, stmt.Expression)
+		}
+
+		if call.Function.String() != tt.expectedCall {
+			t.Errorf("%q: function wrong. expected=%q, got=%q", tt.input, tt.expectedCall, call.Function.String())
+		}
+
+		if len(call.Arguments) != len(tt.expectedArgs) {
+			t.Fatalf("%q: wrong number of arguments. expected=%d, got=%d", tt.input, len(tt.expectedArgs), len(call.Arguments))
+		}
+
+		for i, arg := range tt.expectedArgs {
+			if call.Arguments[i].String() != arg {
+				t.Errorf("%q: argument %d wrong. expected=%q, got=%q", tt.input, i, arg, call.Arguments[i].String())
+			}
+		}
+
+		span := call.Span()
+		got := fmt.Sprintf("%d:%d-%d:%d", span.Start.Line, span.Start.Column, span.End.Line, span.End.Column)
+		if got != tt.expectedSpan {
+			t.Errorf("%q: span wrong. expected=%s, got=%s", tt.input, tt.expectedSpan, got)
+		}
+
+		if call.String() != tt.expectedPrint {
+			t.Errorf("%q: String() wrong. expected=%q, got=%q", tt.input, tt.expectedPrint, call.String())
+		}
+	}
+}
+
+func TestParserErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements int
+	}{
+		{
+			"fr x = ;\nfr y = 5\nfr = 3\nyeet y",
+			[]string{
+				"1:8: you can't lead with a ; - that's not how you begin things! 🤷‍♀️",
+				"3:4: bruh I needed a identifier, why did you hit me with a = instead 🤦‍♀️",
+			},
+			2,
+		},
+		{
+			"fr f = cook(a) {\n  fr = 1;\n  fr b = (a + ;\n  yeet b\n}\nfr z 5",
+			[]string{
+				"2:6: bruh I needed a identifier, why did you hit me with a = instead 🤦‍♀️",
+				"3:15: you can't lead with a ; - that's not how you begin things! 🤷‍♀️",
+				"6:6: bruh I needed a =, why did you hit me with a integer instead 🤦‍♀️",
+			},
+			1,
+		},
+		{
+			"vibe (x {\n  fr a = 1;\n}\nfr b = 2 +;\nfr c = 3",
+			[]string{
+				"1:9: bruh I needed a ), why did you hit me with a { instead 🤦‍♀️",
+				"4:11: you can't lead with a ; - that's not how you begin things! 🤷‍♀️",
+			},
+			1,
+		},
+		{
+			"stalk (i in [1, 2]) { fr x = }\nfr q = 1",
+			[]string{
+				"1:30: you can't lead with a } - that's not how you begin things! 🤷‍♀️",
+			},
+			2,
+		},
+		{
+			"fr x = [1, 2;\nfr y = 3;\nfr z = {1: };",
+			[]string{
+				"1:13: bruh I needed a ], why did you hit me with a ; instead 🤦‍♀️",
+				"3:12: you can't lead with a } - that's not how you begin things! 🤷‍♀️",
+			},
+			1,
+		},
+		{
+			"add(1, 2\nfr y = 1",
+			[]string{
+				"2:1: bruh I needed a ), why did you hit me with a fr instead 🤦‍♀️",
+			},
+			1,
+		},
+		{
+			"fr t = @;\nfr u = 1 + 2",
+			[]string{
+				"1:8: wtf is \"@\" doing here? i don't know that character 🤨",
+			},
+			1,
+		},
+		{
+			"fr b =\nfr c = )\nfr d = ]",
+			[]string{
+				"2:1: you can't lead with a fr - that's not how you begin things! 🤷‍♀️",
+				"2:8: you can't lead with a ) - that's not how you begin things! 🤷‍♀️",
+				"3:8: you can't lead with a ] - that's not how you begin things! 🤷‍♀️",
+			},
+			0,
+		},
+		{
+			"fr b =\ncook f( { 1 }\nfr d = ]\nfr e = )\nfr g = 1",
+			[]string{
+				"2:6: bruh I needed a (, why did you hit me with a identifier instead 🤦‍♀️",
+				"3:8: you can't lead with a ] - that's not how you begin things! 🤷‍♀️",
+				"4:8: you can't lead with a ) - that's not how you begin things! 🤷‍♀️",
+			},
+			1,
+		},
+		{
+			"fr h = f(cook() { fr x = (1 + \n fr y = 2 }, [\nfr z = 3",
+			[]string{
+				"2:2: you can't lead with a fr - that's not how you begin things! 🤷‍♀️",
+				"3:1: you can't lead with a fr - that's not how you begin things! 🤷‍♀️",
+			},
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("%q: expected %d errors, got %d: %q", tt.input, len(tt.expectedErrors), len(errors), errors)
+			continue
+		}
+
+		for i, msg := range tt.expectedErrors {
+			if errors[i] != msg {
+				t.Errorf("%q: errors[%d] wrong. expected=%q, got=%q", tt.input, i, msg, errors[i])
+			}
+		}
+
+		if len(program.Statements) != tt.expectedStatements {
+			t.Errorf("%q: expected %d statements to survive, got %d: %s",
+				tt.input, tt.expectedStatements, len(program.Statements), program.String())
+		}
+	}
+}
+
+func TestLoopControlAndEmptyStatements(t *testing.T) {
+	input := `stalk (x in xs) { vibe (x) { bounce } pass };
+onRepeat (noCap) { bounce };;
+fr y = 1;`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 3 {
+		t.Fatalf("program.Statements does not contain 3 statements. got=%d", len(program.Statements))
+	}
+
+	loop, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ForStatement. got=%T", program.Statements[0])
+	}
+
+	if len(loop.Body.Statements) != 2 {
+		t.Fatalf("loop body does not contain 2 statements. got=%d", len(loop.Body.Statements))
+	}
+
+	if _, ok := loop.Body.Statements[1].(*ast.ContinueStatement); !ok {
+		t.Errorf("loop body statement 2 is not *ast.ContinueStatement. got=%T", loop.Body.Statements[1])
+	}
+}
+
+func TestParserDiagnostics(t *testing.T) {
+	input := "fr x = (1 + 2;\nfr = 5;\nfr s = \"yo\\q\";"
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	expected := []diagnostic.Diagnostic{
+		{
+			Severity: diagnostic.Error,
+			Code:     diagnostic.UnexpectedToken,
+			Span: token.Span{
+				Start: token.Position{Line: 1, Column: 14},
+				End:   token.Position{Line: 1, Column: 15},
+			},
+			Expected: ")",
+			Actual:   ";",
+			Hints:    []string{"every ( needs a ) to close it"},
+		},
+		{
+			Severity: diagnostic.Error,
+			Code:     diagnostic.UnexpectedToken,
+			Span: token.Span{
+				Start: token.Position{Line: 2, Column: 4},
+				End:   token.Position{Line: 2, Column: 5},
+			},
+			Expected: "identifier",
+			Actual:   "=",
+		},
+		{
+			Severity: diagnostic.Error,
+			Code:     diagnostic.InvalidEscape,
+			Span: token.Span{
+				Start: token.Position{Line: 3, Column: 11},
+				End:   token.Position{Line: 3, Column: 12},
+			},
+		},
+	}
+
+	diagnostics := p.Diagnostics()
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %d: %v", len(expected), len(diagnostics), p.Errors())
+	}
+
+	for i, want := range expected {
+		got := diagnostics[i]
+
+		if got.Severity != want.Severity || got.Code != want.Code {
+			t.Errorf("diagnostics[%d] wrong kind. expected=%s %s, got=%s %s",
+				i, want.Severity, want.Code, got.Severity, got.Code)
+		}
+
+		if got.Span != want.Span {
+			t.Errorf("diagnostics[%d] span wrong. expected=%v-%v, got=%v-%v", i, want.Span.Start, want.Span.End, got.Span.Start, got.Span.End)
+		}
+
+		if got.Expected != want.Expected || got.Actual != want.Actual {
+			t.Errorf("diagnostics[%d] wrong tokens. expected=%q/%q, got=%q/%q",
+				i, want.Expected, want.Actual, got.Expected, got.Actual)
+		}
+
+		if strings.Join(got.Hints, "|") != strings.Join(want.Hints, "|") {
+			t.Errorf("diagnostics[%d] hints wrong. expected=%q, got=%q", i, want.Hints, got.Hints)
+		}
+
+		if got.Message == "" {
+			t.Errorf("diagnostics[%d] has no message", i)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 const (
@@ -12,13 +14,36 @@ const (
 	FLOAT  = "float"      // 1.3456
 	STRING = "string"     // "foobar"
 
+	// Pieces of an interpolated string such as "yo ${name}, sup ${x}!"
+	TEMPLATE_HEAD   = "string before ${"   // "yo ${
+	TEMPLATE_MIDDLE = "string between ${}" // }, sup ${
+	TEMPLATE_TAIL   = "string after }"     // }!"
+
 	// Operators
-	ASSIGN   = "="
-	PLUS     = "+"
-	MINUS    = "-"
-	ASTERISK = "*"
-	SLASH    = "/"
-	MODULO   = "%"
+	ASSIGN    = "="
+	PLUS      = "+"
+	MINUS     = "-"
+	ASTERISK  = "*"
+	SLASH     = "/"
+	MODULO    = "%"
+	POWER     = "**"
+	FLOOR_DIV = "~/"
+
+	BIT_AND     = "&"
+	BIT_OR      = "|"
+	BIT_XOR     = "^"
+	BIT_NOT     = "~"
+	SHIFT_LEFT  = "<<"
+	SHIFT_RIGHT = ">>"
+
+	COALESCE = "??"
+	PIPE     = "|>"
+
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+	MODULO_ASSIGN   = "%="
 
 	LT     = "<"
 	GT     = ">"
@@ -31,15 +56,18 @@ const (
 
 	// Delimiters
 	COMMA     = ","
+	DOTDOT    = ".."
 	SEMICOLON = ";"
 	COLON     = ":"
 
-	LPAREN   = "("
-	RPAREN   = ")"
-	LBRACE   = "{"
-	RBRACE   = "}"
-	LBRACKET = "["
-	RBRACKET = "]"
+	LPAREN            = "("
+	RPAREN            = ")"
+	LBRACE            = "{"
+	RBRACE            = "}"
+	LBRACKET          = "["
+	OPTIONAL_LBRACKET = "?["
+	ELLIPSIS          = "..."
+	RBRACKET          = "]"
 
 	// Keywords
 	BANG     = "nah"
@@ -57,13 +85,51 @@ const (
 	CONTINUE = "pass"
 	BREAK    = "bounce"
 	NULL     = "ghosted"
+	BY       = "by"
+	CONST    = "deadass"
 )
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Start   Position // position of the first character of the token
+	End     Position // position just after the last character of the token
 }
 
+// Position is a location in a source file. Lines and columns start at 1,
+// the zero value means the position is unknown.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+func (p Position) String() string {
+	if !p.IsValid() {
+		if p.File != "" {
+			return p.File
+		}
+		return "-"
+	}
+
+	if p.File != "" {
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Span is the range of source covered by a token or a node
+type Span struct {
+	Start Position
+	End   Position
+}
+
+func (s Span) String() string { return s.Start.String() }
+
+func (t Token) Span() Span { return Span{Start: t.Start, End: t.End} }
+
 var keywords = map[string]TokenType{
 	"cook":     FUNCTION,
 	"fr":       LET,
@@ -84,6 +150,8 @@ var keywords = map[string]TokenType{
 	"ghosted":  NULL,
 	"and":      AND,
 	"or":       OR,
+	"by":       BY,
+	"deadass":  CONST,
 }
 
 func LookupIdent(ident string) TokenType {